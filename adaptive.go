@@ -0,0 +1,106 @@
+package main
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+/*
+
+	Adaptive concurrency for batches of HTTP GET calls.
+
+	The batch is sent in rounds, the size of each round is the current
+	concurrency, which grows by one after a healthy round and is halved
+	after a round with failures or with a latency much higher than the
+	best one observed (additive increase, multiplicative decrease).
+
+*/
+
+// Total number of attempts made for a URL, counting the first one,
+// a URL that failed is sent again in a later round until it reaches it
+const adaptiveMaxAttempts = 3
+
+// A round whose average latency exceeds the best average by this
+// factor is considered a sign of an overloaded server
+const adaptiveLatencyFactor = 2
+
+// Function that makes an HTTP GET request and reports whether it
+// failed, transport errors and 429 or 5xx responses are failures
+func getAndClassify(url string) (Result, bool) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return Error[error]{Value: err}, true
+	}
+	resp, body, err := fetchBody(http.DefaultClient, req)
+	if err != nil {
+		return Error[error]{Value: err}, true
+	}
+	if isRetryableStatus(resp.StatusCode) {
+		return Error[error]{Value: &StatusError{StatusCode: resp.StatusCode, Status: resp.Status}}, true
+	}
+	return Ok[RequestBodyAsString]{Value: string(body)}, false
+}
+
+// Function that makes a chain of HTTP GET calls starting with a
+// concurrency of one and adapting it to the errors and latency
+// observed, so the server is not overwhelmed while throughput is
+// maximized, failed requests are sent again in later rounds until
+// they were attempted adaptiveMaxAttempts times in total
+// It returns the results in input order and the concurrency reached
+// at the end of the batch
+func ChainAdaptive(urls []string) (results []Result, finalConcurrency int) {
+	results = make([]Result, len(urls))
+	attempts := make([]int, len(urls))
+	pending := make([]int, len(urls))
+	for i := range pending {
+		pending[i] = i
+	}
+
+	concurrency := 1
+	var bestLatency time.Duration
+	for len(pending) > 0 {
+		round := pending[:min(concurrency, len(pending))]
+		pending = pending[len(round):]
+
+		failed := make([]bool, len(round))
+		latencies := make([]time.Duration, len(round))
+		var wg sync.WaitGroup
+		for j, i := range round {
+			wg.Add(1)
+			go func(j, i int) {
+				defer wg.Done()
+				start := time.Now()
+				results[i], failed[j] = getAndClassify(urls[i])
+				latencies[j] = time.Since(start)
+			}(j, i)
+		}
+		wg.Wait()
+
+		var retry []int
+		var total time.Duration
+		for j, i := range round {
+			total += latencies[j]
+			attempts[i]++
+			if failed[j] && attempts[i] < adaptiveMaxAttempts {
+				retry = append(retry, i)
+			}
+		}
+		avgLatency := total / time.Duration(len(round))
+		if bestLatency == 0 || avgLatency < bestLatency {
+			bestLatency = avgLatency
+		}
+
+		overloaded := avgLatency > adaptiveLatencyFactor*bestLatency
+		for _, f := range failed {
+			overloaded = overloaded || f
+		}
+		if overloaded {
+			concurrency = max(concurrency/2, 1)
+		} else if concurrency < len(urls) {
+			concurrency++
+		}
+		pending = append(retry, pending...)
+	}
+	return results, concurrency
+}
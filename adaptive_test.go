@@ -0,0 +1,41 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestChainAdaptiveBacksOff(t *testing.T) {
+	const limit = 3
+	var inFlight, rejected atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inFlight.Add(1)
+		defer inFlight.Add(-1)
+		time.Sleep(20 * time.Millisecond)
+		if inFlight.Load() > limit {
+			rejected.Add(1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	urls := make([]string, 20)
+	for i := range urls {
+		urls[i] = server.URL
+	}
+	results, concurrency := ChainAdaptive(urls)
+	if rejected.Load() == 0 {
+		t.Error("the concurrency never went above the server limit")
+	}
+	if concurrency > limit+1 {
+		t.Errorf("final concurrency = %d, want at most %d", concurrency, limit+1)
+	}
+	for i, result := range results {
+		if _, isOk := result.(Ok[RequestBodyAsString]); !isOk {
+			t.Errorf("results[%d] = %v, want an Ok", i, result)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"container/list"
+	"sync"
+)
+
+/*
+
+	LRUCachingClient caches the Ok results of HTTP GET calls by URL,
+	keeping at most a fixed number of entries so memory stays capped
+	in long-running processes that fetch many distinct URLs.
+
+*/
+
+type LRUCachingClient struct {
+	maxEntries int
+	get        func(url string) Result
+
+	mu      sync.Mutex
+	order   *list.List // front is the most recently used entry
+	entries map[string]*list.Element
+}
+
+// Cached Result of a URL, stored in the order list
+type cacheEntry struct {
+	url    string
+	result Result
+}
+
+// Function to create an LRUCachingClient that keeps at most
+// maxEntries results, evicting the least recently used one when full
+// A maxEntries lower than one disables the limit
+func NewLRUCachingClient(maxEntries int) *LRUCachingClient {
+	return &LRUCachingClient{
+		maxEntries: maxEntries,
+		get:        httpGetResult,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+// Function that returns the cached Result of a URL, or makes an
+// HTTP GET request and caches its Result when it is an Ok
+// Errors are not cached, so a failed URL is fetched again next time
+func (c *LRUCachingClient) Get(url string) Result {
+	c.mu.Lock()
+	if elem, ok := c.entries[url]; ok {
+		c.order.MoveToFront(elem)
+		result := elem.Value.(*cacheEntry).result
+		c.mu.Unlock()
+		return result
+	}
+	c.mu.Unlock()
+
+	result := c.get(url)
+	if _, isOk := result.(okValuer); isOk {
+		c.add(url, result)
+	}
+	return result
+}
+
+// Stores a Result as the most recently used entry,
+// evicting the least recently used one when the cache is full
+func (c *LRUCachingClient) add(url string, result Result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[url]; ok {
+		elem.Value.(*cacheEntry).result = result
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[url] = c.order.PushFront(&cacheEntry{url: url, result: result})
+	if c.maxEntries > 0 && c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).url)
+	}
+}
+
+// Function that reports whether a URL is currently cached,
+// without changing its position in the eviction order
+func (c *LRUCachingClient) Contains(url string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.entries[url]
+	return ok
+}
+
+// Function that returns the number of cached results
+func (c *LRUCachingClient) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
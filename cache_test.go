@@ -0,0 +1,22 @@
+package main
+
+import "testing"
+
+func TestLRUCachingClientEviction(t *testing.T) {
+	server := newPathServer(t)
+	client := NewLRUCachingClient(2)
+	client.Get(server.URL + "/a")
+	client.Get(server.URL + "/b")
+	client.Get(server.URL + "/a") // a becomes the most recently used
+	client.Get(server.URL + "/c") // evicts b
+
+	if client.Len() != 2 {
+		t.Errorf("Len = %d, want 2", client.Len())
+	}
+	if !client.Contains(server.URL+"/a") || !client.Contains(server.URL+"/c") {
+		t.Error("recently used entries were evicted")
+	}
+	if client.Contains(server.URL + "/b") {
+		t.Error("least recently used entry was not evicted")
+	}
+}
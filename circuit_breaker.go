@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	neturl "net/url"
+	"sync"
+	"time"
+)
+
+/*
+
+	CircuitBreakerClient stops sending requests to a host after a run
+	of consecutive failures, so a broken host is not hammered with
+	requests that are going to fail anyway.
+
+	Once the threshold of consecutive failures is reached the circuit
+	of the host opens and its requests fail immediately, after the
+	cooldown one trial request is let through, when it succeeds the
+	circuit closes again, otherwise it stays open for another cooldown.
+
+*/
+
+// Error returned for the requests short-circuited by an open circuit
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+type CircuitBreakerClient struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu    sync.Mutex
+	hosts map[string]*breakerState
+}
+
+// State of the circuit of a single host
+type breakerState struct {
+	failures int
+	openedAt time.Time
+	trial    bool // a trial request is in flight
+}
+
+// Function to create a CircuitBreakerClient that opens the circuit of
+// a host after threshold consecutive failures and keeps it open for
+// cooldown, transport errors and 429 or 5xx responses are failures
+func NewCircuitBreakerClient(threshold int, cooldown time.Duration) *CircuitBreakerClient {
+	return &CircuitBreakerClient{
+		threshold: threshold,
+		cooldown:  cooldown,
+		hosts:     make(map[string]*breakerState),
+	}
+}
+
+// Reports whether a request to the host can be sent, marking it as
+// the trial request when the cooldown of an open circuit has elapsed
+func (c *CircuitBreakerClient) allow(host string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	state, ok := c.hosts[host]
+	if !ok || state.failures < c.threshold {
+		return true
+	}
+	if state.trial || time.Since(state.openedAt) < c.cooldown {
+		return false
+	}
+	state.trial = true
+	return true
+}
+
+// Records the outcome of a request to the host
+func (c *CircuitBreakerClient) record(host string, failed bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	state, ok := c.hosts[host]
+	if !ok {
+		state = &breakerState{}
+		c.hosts[host] = state
+	}
+	state.trial = false
+	if !failed {
+		state.failures = 0
+		return
+	}
+	state.failures++
+	if state.failures >= c.threshold {
+		state.openedAt = time.Now()
+	}
+}
+
+// Function that makes an HTTP GET request unless the circuit of its
+// host is open, in which case an Error with ErrCircuitOpen is returned
+// without hitting the network
+func (c *CircuitBreakerClient) Get(url string) Result {
+	u, err := neturl.Parse(url)
+	if err != nil {
+		return Error[error]{Value: err}
+	}
+	if !c.allow(u.Host) {
+		return Error[error]{Value: fmt.Errorf("%w: %s", ErrCircuitOpen, u.Host)}
+	}
+	result, failed := getAndClassify(url)
+	c.record(u.Host, failed)
+	return result
+}
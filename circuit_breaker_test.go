@@ -0,0 +1,42 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerClient(t *testing.T) {
+	var hits atomic.Int32
+	var healthy atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	const cooldown = 50 * time.Millisecond
+	client := NewCircuitBreakerClient(2, cooldown)
+	client.Get(server.URL)
+	client.Get(server.URL)
+	if err := resultError(client.Get(server.URL)); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("request after the threshold = %v, want ErrCircuitOpen", err)
+	}
+	if n := hits.Load(); n != 2 {
+		t.Errorf("server hit %d times, want 2", n)
+	}
+
+	healthy.Store(true)
+	time.Sleep(cooldown)
+	if got := client.Get(server.URL); got != (Ok[RequestBodyAsString]{Value: ""}) {
+		t.Errorf("trial request after the cooldown = %v, want an Ok", got)
+	}
+	if got := client.Get(server.URL); got != (Ok[RequestBodyAsString]{Value: ""}) {
+		t.Errorf("request after a successful trial = %v, want an Ok", got)
+	}
+}
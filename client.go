@@ -0,0 +1,219 @@
+package main
+
+import (
+	"crypto/tls"
+	"net/http"
+	"sync"
+	"time"
+)
+
+/*
+
+	Client is a configurable HTTP client that returns Result values,
+	it wraps an http.Client so the transport used by the call chains
+	can be tuned instead of relying on http.DefaultClient.
+
+	A Client is configured with ClientOption values passed to
+	NewClient or NewTunedClient, and it is safe to use from
+	multiple goroutines.
+
+*/
+
+type Client struct {
+	httpClient *http.Client
+	transport  *http.Transport
+	hooks      ClientHooks
+
+	// Limit of in-flight requests per host, zero means no limit
+	perHostLimit int
+	hostMu       sync.Mutex
+	hostSems     map[string]chan struct{}
+
+	// Exponential moving average of the request durations
+	latencyMu  sync.Mutex
+	avgLatency time.Duration
+	samples    int
+}
+
+// Weight of the newest request duration in the latency average
+const latencyEMAAlpha = 0.2
+
+// Option used to configure a Client when it is created
+type ClientOption func(*Client)
+
+// Functions called by a Client around each request, nil hooks are skipped
+type ClientHooks struct {
+	// Called before sending a request, when it returns an error the
+	// request is not sent and the Result is an Error with that error,
+	// useful for deterministic tests and for circuit breaking
+	BeforeRequest func(*http.Request) error
+}
+
+// Function to create a Client with the default transport settings
+func NewClient(opts ...ClientOption) *Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	return newClient(transport, opts)
+}
+
+// Function to create a Client with a tuned connection pool,
+// maxIdleConns limits the idle connections kept in total,
+// maxConnsPerHost limits the connections (and idle connections)
+// opened to a single host and idleTimeout is how long an idle
+// connection is kept before closing it
+// Reusing connections avoids connection churn when a batch
+// hits the same host repeatedly
+func NewTunedClient(maxIdleConns, maxConnsPerHost int, idleTimeout time.Duration, opts ...ClientOption) *Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = maxIdleConns
+	transport.MaxIdleConnsPerHost = maxConnsPerHost
+	transport.MaxConnsPerHost = maxConnsPerHost
+	transport.IdleConnTimeout = idleTimeout
+	return newClient(transport, opts)
+}
+
+func newClient(transport *http.Transport, opts []ClientOption) *Client {
+	c := &Client{
+		httpClient: &http.Client{Transport: transport},
+		transport:  transport,
+		hostSems:   make(map[string]chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Option that limits the number of in-flight requests to each host,
+// requests to a host that reached the limit wait for a free slot
+// while requests to other hosts proceed
+// This matters when a batch hits many URLs but several of them
+// share a host that rate-limits aggressively
+func WithPerHostLimit(n int) ClientOption {
+	return func(c *Client) {
+		c.perHostLimit = n
+	}
+}
+
+// Option that enables or disables HTTP/2 for HTTPS requests,
+// when disabled every request uses HTTP/1.1
+func WithHTTP2(enabled bool) ClientOption {
+	return func(c *Client) {
+		c.transport.ForceAttemptHTTP2 = enabled
+		if !enabled {
+			c.transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+		} else {
+			c.transport.TLSNextProto = nil
+		}
+	}
+}
+
+// Option that sets the TLS configuration used for HTTPS requests,
+// for example to trust a custom certificate pool or to pin certificates
+func WithTLSConfig(config *tls.Config) ClientOption {
+	return func(c *Client) {
+		c.transport.TLSClientConfig = config
+	}
+}
+
+// Option that sets the hooks called by the Client around each request
+func WithHooks(hooks ClientHooks) ClientOption {
+	return func(c *Client) {
+		c.hooks = hooks
+	}
+}
+
+// Returns the semaphore of a host, creating it on first use
+func (c *Client) hostSem(host string) chan struct{} {
+	c.hostMu.Lock()
+	defer c.hostMu.Unlock()
+	sem, ok := c.hostSems[host]
+	if !ok {
+		sem = make(chan struct{}, c.perHostLimit)
+		c.hostSems[host] = sem
+	}
+	return sem
+}
+
+// Function that sends a request with the Client
+// and packs the body or the error into a Result
+func (c *Client) Do(req *http.Request) Result {
+	if c.hooks.BeforeRequest != nil {
+		if err := c.hooks.BeforeRequest(req); err != nil {
+			return Error[error]{Value: err}
+		}
+	}
+	if c.perHostLimit > 0 {
+		sem := c.hostSem(req.URL.Host)
+		sem <- struct{}{}
+		defer func() { <-sem }()
+	}
+	start := time.Now()
+	result := fetchResult(c.httpClient, req)
+	c.recordLatency(time.Since(start))
+	return result
+}
+
+// Updates the moving average of request durations with a new sample,
+// the first sample is taken as the initial average
+func (c *Client) recordLatency(d time.Duration) {
+	c.latencyMu.Lock()
+	defer c.latencyMu.Unlock()
+	if c.samples == 0 {
+		c.avgLatency = d
+	} else {
+		c.avgLatency += time.Duration(latencyEMAAlpha * float64(d-c.avgLatency))
+	}
+	c.samples++
+}
+
+// Function that returns the exponential moving average of the
+// duration of the requests made with the Client, zero before the
+// first request, callers can use it to tune concurrency adaptively
+func (c *Client) AvgLatency() time.Duration {
+	c.latencyMu.Lock()
+	defer c.latencyMu.Unlock()
+	return c.avgLatency
+}
+
+// Function that makes an HTTP GET request with the Client
+// and packs the outcome into a Result
+func (c *Client) Get(url string) Result {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return Error[error]{Value: err}
+	}
+	return c.Do(req)
+}
+
+// Function that makes a chain of HTTP GET calls with the Client,
+// with at most concurrency requests in flight, and returns the
+// results in input order
+// A concurrency lower than one means no limit
+func (c *Client) Chain(urls []string, concurrency int) []Result {
+	return chainWithConcurrency(urls, concurrency, c.Get)
+}
+
+// Function type that sends a request and returns its response,
+// it implements http.RoundTripper so it can be used as a transport
+type RoundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f RoundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Middleware wraps the next step of the transport of a Client to add
+// behavior around each request, like logging, authentication or retries
+type Middleware func(next RoundTripFunc) RoundTripFunc
+
+// Function that adds middlewares around the transport of the Client,
+// the first middleware given is the outermost one, so it runs first
+// for each request, and middlewares added by a later call to Use wrap
+// the ones added before
+// Use is not safe to call while the Client is sending requests
+func (c *Client) Use(mw ...Middleware) {
+	next := RoundTripFunc(c.httpClient.Transport.RoundTrip)
+	for i := len(mw) - 1; i >= 0; i-- {
+		next = mw[i](next)
+	}
+	c.httpClient.Transport = next
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Listener that counts the connections it accepts
+type countingListener struct {
+	net.Listener
+	accepted atomic.Int32
+}
+
+func (l *countingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err == nil {
+		l.accepted.Add(1)
+	}
+	return conn, err
+}
+
+func TestNewTunedClientReusesConnections(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	listener := &countingListener{Listener: server.Listener}
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	urls := make([]string, 20)
+	for i := range urls {
+		urls[i] = server.URL
+	}
+	NewTunedClient(10, 2, time.Minute).Chain(urls, 4)
+	if n := listener.accepted.Load(); n > 2 {
+		t.Errorf("server accepted %d connections, want at most 2", n)
+	}
+}
+
+func TestWithPerHostLimit(t *testing.T) {
+	var inFlight, maxInFlight sync.Map
+	newServer := func() *httptest.Server {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			counter, _ := inFlight.LoadOrStore(r.Host, new(atomic.Int32))
+			peak, _ := maxInFlight.LoadOrStore(r.Host, new(atomic.Int32))
+			n := counter.(*atomic.Int32).Add(1)
+			for {
+				old := peak.(*atomic.Int32).Load()
+				if n <= old || peak.(*atomic.Int32).CompareAndSwap(old, n) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			counter.(*atomic.Int32).Add(-1)
+		}))
+		t.Cleanup(server.Close)
+		return server
+	}
+	first, second := newServer(), newServer()
+
+	var urls []string
+	for i := 0; i < 6; i++ {
+		urls = append(urls, first.URL, second.URL)
+	}
+	NewClient(WithPerHostLimit(2)).Chain(urls, 0)
+	reached := false
+	maxInFlight.Range(func(host, peak any) bool {
+		n := peak.(*atomic.Int32).Load()
+		if n > 2 {
+			t.Errorf("host %v had %d requests in flight, want at most 2", host, n)
+		}
+		reached = reached || n == 2
+		return true
+	})
+	if !reached {
+		t.Error("no host reached 2 requests in flight, the limit was never hit")
+	}
+}
+
+func TestClientAvgLatency(t *testing.T) {
+	const delay = 30 * time.Millisecond
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delay)
+	}))
+	defer server.Close()
+
+	client := NewClient()
+	if client.AvgLatency() != 0 {
+		t.Errorf("AvgLatency before any request = %v, want 0", client.AvgLatency())
+	}
+	for i := 0; i < 10; i++ {
+		client.Get(server.URL)
+	}
+	if avg := client.AvgLatency(); avg < delay || avg > 3*delay {
+		t.Errorf("AvgLatency = %v, want between %v and %v", avg, delay, 3*delay)
+	}
+}
+
+func TestClientWithTLSConfig(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Proto)
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+	client := NewClient(WithTLSConfig(&tls.Config{RootCAs: pool}), WithHTTP2(true))
+	if got := client.Get(server.URL); got != (Ok[RequestBodyAsString]{Value: "HTTP/2.0"}) {
+		t.Errorf("Get over TLS = %v, want Ok{HTTP/2.0}", got)
+	}
+	if _, isErr := NewClient().Get(server.URL).(Error[error]); !isErr {
+		t.Error("Get without the server certificate did not return an Error")
+	}
+}
+
+func TestWithHooksInjectsFailures(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		fmt.Fprint(w, r.URL.Path)
+	}))
+	defer server.Close()
+
+	errInjected := errors.New("injected failure")
+	client := NewClient(WithHooks(ClientHooks{BeforeRequest: func(req *http.Request) error {
+		if req.URL.Path == "/fail" {
+			return errInjected
+		}
+		return nil
+	}}))
+	results := client.Chain([]string{server.URL + "/ok", server.URL + "/fail"}, 0)
+	if results[0] != (Ok[RequestBodyAsString]{Value: "/ok"}) {
+		t.Errorf("results[0] = %v, want Ok{/ok}", results[0])
+	}
+	if results[1] != (Error[error]{Value: errInjected}) {
+		t.Errorf("results[1] = %v, want Error{injected failure}", results[1])
+	}
+	if n := hits.Load(); n != 1 {
+		t.Errorf("server hit %d times, want 1", n)
+	}
+}
+
+func TestClientUseMiddlewareOrder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Header.Get("X-Trace"))
+	}))
+	defer server.Close()
+
+	var order []string
+	addHeader := func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			order = append(order, "header")
+			req.Header.Set("X-Trace", "on")
+			return next(req)
+		}
+	}
+	calls := 0
+	countCalls := func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			order = append(order, "count")
+			calls++
+			return next(req)
+		}
+	}
+	client := NewClient()
+	client.Use(addHeader, countCalls)
+	for i := 0; i < 2; i++ {
+		if got := client.Get(server.URL); got != (Ok[RequestBodyAsString]{Value: "on"}) {
+			t.Errorf("Get = %v, want Ok{on}", got)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("counting middleware ran %d times, want 2", calls)
+	}
+	if want := []string{"header", "count", "header", "count"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("middleware order = %v, want %v", order, want)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+/*
+
+	Dispatcher is a long-lived pool of workers that makes the HTTP GET
+	calls of the URLs submitted to it and delivers their results on a
+	channel, for services that keep receiving URLs instead of having a
+	fixed batch.
+
+*/
+
+// Error returned when a URL is submitted after Shutdown was called
+var ErrDispatcherClosed = errors.New("dispatcher is shut down")
+
+type Dispatcher struct {
+	concurrency int
+	jobs        chan string
+	results     chan Result
+	ctx         context.Context
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
+	startOnce   sync.Once
+	done        chan struct{}
+
+	mu      sync.RWMutex
+	closed  bool
+	submits sync.WaitGroup // Submit calls still sending to jobs
+}
+
+// Function to create a Dispatcher with concurrency workers
+// (at least one) and a queue of queueSize submitted URLs
+func NewDispatcher(concurrency, queueSize int) *Dispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Dispatcher{
+		concurrency: max(concurrency, 1),
+		jobs:        make(chan string, max(queueSize, 0)),
+		results:     make(chan Result),
+		ctx:         ctx,
+		cancel:      cancel,
+		done:        make(chan struct{}),
+	}
+}
+
+// Function that starts the workers, calling it more than once does nothing
+func (d *Dispatcher) Start() {
+	d.startOnce.Do(func() {
+		for w := 0; w < d.concurrency; w++ {
+			d.wg.Add(1)
+			go func() {
+				defer d.wg.Done()
+				for url := range d.jobs {
+					select {
+					case d.results <- HttpGetCallCtx(d.ctx, url):
+					case <-d.ctx.Done():
+						return
+					}
+				}
+			}()
+		}
+		go func() {
+			d.wg.Wait()
+			close(d.results)
+			close(d.done)
+		}()
+	})
+}
+
+// Function that returns the channel where the results are delivered,
+// in completion order, it is closed once the Dispatcher is shut down
+// The results must be received for the workers to make progress
+func (d *Dispatcher) Results() <-chan Result {
+	return d.results
+}
+
+// Function that queues a URL to be fetched, it blocks while the queue
+// is full and returns ErrDispatcherClosed after Shutdown was called,
+// or when the Shutdown context ends while it is still blocked
+func (d *Dispatcher) Submit(url string) error {
+	d.mu.RLock()
+	if d.closed {
+		d.mu.RUnlock()
+		return ErrDispatcherClosed
+	}
+	d.submits.Add(1)
+	d.mu.RUnlock()
+	defer d.submits.Done()
+
+	select {
+	case d.jobs <- url:
+		return nil
+	case <-d.ctx.Done():
+		return ErrDispatcherClosed
+	}
+}
+
+// Function that stops accepting URLs and waits until every queued and
+// in-flight request has delivered its Result, when the context ends
+// first the outstanding requests are cancelled, their results dropped
+// and the context error is returned
+// The Results channel is closed when it returns in both cases
+func (d *Dispatcher) Shutdown(ctx context.Context) error {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		// the queue is closed once the Submit calls already accepted
+		// have sent their URL, so no send can hit a closed channel
+		go func() {
+			d.submits.Wait()
+			close(d.jobs)
+		}()
+	}
+	d.mu.Unlock()
+	d.Start()
+
+	select {
+	case <-d.done:
+		d.cancel()
+		return nil
+	case <-ctx.Done():
+		d.cancel()
+		<-d.done
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDispatcherShutdownDrains(t *testing.T) {
+	server := newPathServer(t)
+	d := NewDispatcher(2, 10)
+	d.Start()
+	received := make(chan []Result)
+	go func() {
+		var results []Result
+		for result := range d.Results() {
+			results = append(results, result)
+		}
+		received <- results
+	}()
+
+	for _, path := range []string{"/a", "/b", "/c", "/d", "/e"} {
+		if err := d.Submit(server.URL + path); err != nil {
+			t.Fatalf("Submit(%s) returned error %v", path, err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := d.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown returned error %v", err)
+	}
+	if results := <-received; len(results) != 5 {
+		t.Errorf("received %d results, want 5", len(results))
+	}
+	if err := d.Submit(server.URL); !errors.Is(err, ErrDispatcherClosed) {
+		t.Errorf("Submit after Shutdown returned %v, want ErrDispatcherClosed", err)
+	}
+}
+
+func TestDispatcherShutdownDeadline(t *testing.T) {
+	server := newPathServer(t)
+	d := NewDispatcher(1, 0)
+	d.Start()
+	d.Submit(server.URL) // its result is never received
+	blocked := make(chan error)
+	go func() { blocked <- d.Submit(server.URL) }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := d.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown returned %v, want context.DeadlineExceeded", err)
+	}
+	select {
+	case _, open := <-d.Results():
+		if open {
+			t.Error("received a result after Shutdown returned")
+		}
+	case <-time.After(time.Second):
+		t.Error("Results not closed after Shutdown returned")
+	}
+	select {
+	case err := <-blocked:
+		if !errors.Is(err, ErrDispatcherClosed) {
+			t.Errorf("blocked Submit returned %v, want ErrDispatcherClosed", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("blocked Submit did not return after Shutdown")
+	}
+}
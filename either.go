@@ -0,0 +1,41 @@
+package main
+
+/*
+
+	The monadic Either interface is a data type that can be Left or
+	Right, unlike Result both sides are typed, so Either[L, R] knows
+	the type of the value it carries on each side.
+
+	By convention Right holds the successful value and Left holds
+	the failure, so the monadic operations are biased to the right
+	and Left values pass through them unchanged.
+
+	This implementation is similar to the Either monad in Haskell.
+
+*/
+
+type Either[L any, R any] interface {
+	isEither(L, R)
+}
+type Left[L any, R any] struct {
+	Value L
+}
+type Right[L any, R any] struct {
+	Value R
+}
+
+func (Left[L, R]) isEither(L, R)  {}
+func (Right[L, R]) isEither(L, R) {}
+
+// Function that chains an operation on the Right value of an Either
+// (monadic bind biased to the right), a Left is returned unchanged
+// and f is not called
+func FlatMapRight[L any, R any, R2 any](e Either[L, R], f func(R) Either[L, R2]) Either[L, R2] {
+	switch e := e.(type) {
+	case Right[L, R]:
+		return f(e.Value)
+	case Left[L, R]:
+		return Left[L, R2]{Value: e.Value}
+	}
+	return nil
+}
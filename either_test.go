@@ -0,0 +1,31 @@
+package main
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestFlatMapRight(t *testing.T) {
+	parse := func(s string) Either[string, int] {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return Left[string, int]{Value: "not a number: " + s}
+		}
+		return Right[string, int]{Value: n}
+	}
+	double := func(n int) Either[string, int] { return Right[string, int]{Value: n * 2} }
+
+	got := FlatMapRight(FlatMapRight(Either[string, string](Right[string, string]{Value: "21"}), parse), double)
+	if got != (Right[string, int]{Value: 42}) {
+		t.Errorf("chain over Right = %v, want Right{42}", got)
+	}
+
+	doubled := false
+	got = FlatMapRight(FlatMapRight(Either[string, string](Right[string, string]{Value: "x"}), parse), func(n int) Either[string, int] {
+		doubled = true
+		return double(n)
+	})
+	if got != (Left[string, int]{Value: "not a number: x"}) || doubled {
+		t.Errorf("chain over Left = %v (second step ran: %v), want Left and no second step", got, doubled)
+	}
+}
@@ -0,0 +1,699 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+/*
+
+	More ways of dispatching HTTP GET calls that return Result values,
+	complementing the AsyncChainOfHttpGetCalls and
+	SyncChainOfHttpGetCalls examples in main.go.
+
+	All of these functions return the results in the same order as the
+	input URLs, so each Result can be matched with its URL by index.
+
+*/
+
+// Function that wraps a response body in a decompressing reader
+// when the server sent it with a gzip or deflate Content-Encoding
+// The http package only decompresses transparently when it asked
+// for gzip itself, so bodies compressed on other terms arrive as is
+// Responses without a body (HEAD, 204, 304 or an empty body) are
+// returned as is even when they carry the header
+func decodeBody(resp *http.Response) (io.Reader, error) {
+	if (resp.Request != nil && resp.Request.Method == http.MethodHead) ||
+		resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusNotModified {
+		return resp.Body, nil
+	}
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	if encoding != "gzip" && encoding != "x-gzip" && encoding != "deflate" {
+		return resp.Body, nil
+	}
+	body := bufio.NewReader(resp.Body)
+	if _, err := body.Peek(1); err == io.EOF {
+		return body, nil
+	}
+	if encoding == "deflate" {
+		return zlib.NewReader(body)
+	}
+	return gzip.NewReader(body)
+}
+
+// Function that sends a request with the given client,
+// reads the whole (decompressed) body and closes it
+func fetchBody(client *http.Client, req *http.Request) (*http.Response, []byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	reader, err := decodeBody(resp)
+	if err != nil {
+		return resp, nil, err
+	}
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return resp, nil, err
+	}
+	return resp, body, nil
+}
+
+// Function that sends a request with the given client
+// and packs the body or the error into a Result
+func fetchResult(client *http.Client, req *http.Request) Result {
+	_, body, err := fetchBody(client, req)
+	if err != nil {
+		return Error[error]{Value: err}
+	}
+	return Ok[RequestBodyAsString]{Value: string(body)}
+}
+
+// Key of the request-scoped logger stored in a context
+type loggerContextKey struct{}
+
+// Function that returns a copy of the context carrying a logger,
+// used by the context-aware HTTP helpers to log each request
+func ContextWithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, logger)
+}
+
+// Function that returns the logger carried by the context,
+// or nil when the context has none
+func LoggerFromContext(ctx context.Context) *slog.Logger {
+	logger, _ := ctx.Value(loggerContextKey{}).(*slog.Logger)
+	return logger
+}
+
+// Function that makes an HTTP GET request bound to a context with
+// the default client and packs the outcome into a Result
+// When the context is cancelled or its deadline passes the request
+// is aborted and an Error with the context error is returned
+// When the context carries a logger (see ContextWithLogger) a start
+// event and then a finish or an error event are logged per request
+func HttpGetCallCtx(ctx context.Context, url string) Result {
+	logger := LoggerFromContext(ctx)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		if logger != nil {
+			logger.ErrorContext(ctx, "request error", "url", url, "error", err)
+		}
+		return Error[error]{Value: err}
+	}
+	if logger != nil {
+		logger.InfoContext(ctx, "request start", "url", url)
+	}
+	start := time.Now()
+	resp, body, err := fetchBody(http.DefaultClient, req)
+	if err != nil {
+		if logger != nil {
+			logger.ErrorContext(ctx, "request error", "url", url, "error", err, "duration", time.Since(start))
+		}
+		return Error[error]{Value: err}
+	}
+	if logger != nil {
+		logger.InfoContext(ctx, "request finish", "url", url, "status", resp.StatusCode, "duration", time.Since(start))
+	}
+	return Ok[RequestBodyAsString]{Value: string(body)}
+}
+
+// Function that makes an HTTP GET request with the default
+// client and packs the outcome into a Result
+func httpGetResult(url string) Result {
+	return HttpGetCallCtx(context.Background(), url)
+}
+
+// Result tagged with the position of its URL in the input slice,
+// used to restore the input order of results received from a channel
+type indexedResult struct {
+	index  int
+	result Result
+}
+
+// Function that makes a chain of HTTP GET calls asynchronously
+// and calls onProgress after each completed request with the number
+// of finished requests and the total number of requests
+// onProgress is always called from the calling goroutine,
+// so it does not need to be thread-safe
+func ChainWithProgress(urls []string, onProgress func(done, total int)) []Result {
+	results := make([]Result, len(urls))
+	ch := make(chan indexedResult, len(urls))
+	for i, url := range urls {
+		go func(i int, url string) {
+			ch <- indexedResult{index: i, result: httpGetResult(url)}
+		}(i, url)
+	}
+	for done := 1; done <= len(urls); done++ {
+		r := <-ch
+		results[r.index] = r.result
+		if onProgress != nil {
+			onProgress(done, len(urls))
+		}
+	}
+	return results
+}
+
+// Returns the number of workers to use for n tasks, a concurrency
+// lower than one means no limit
+func workerCount(concurrency, n int) int {
+	if concurrency <= 0 || concurrency > n {
+		return n
+	}
+	return concurrency
+}
+
+// Function that sends a batch of requests where requests sharing the
+// same key (as returned by keyFn) are sent one after another in
+// submission order, while requests with different keys run concurrently
+// At most concurrency keys are processed at the same time,
+// a concurrency lower than one means no limit
+func ChainKeyedPool(reqs []*http.Request, keyFn func(*http.Request) string, concurrency int) []Result {
+	results := make([]Result, len(reqs))
+	var keys []string
+	groups := make(map[string][]int)
+	for i, req := range reqs {
+		key := keyFn(req)
+		if _, seen := groups[key]; !seen {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], i)
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, workerCount(concurrency, len(keys)))
+	for _, key := range keys {
+		wg.Add(1)
+		go func(indexes []int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			for _, i := range indexes {
+				results[i] = fetchResult(http.DefaultClient, reqs[i])
+			}
+		}(groups[key])
+	}
+	wg.Wait()
+	return results
+}
+
+// Error used for the requests of a batch that did not finish
+// before the deadline of the whole batch
+var ErrBatchTimeout = errors.New("batch deadline exceeded")
+
+// Function that makes a chain of HTTP GET calls asynchronously with a
+// single deadline for the whole batch, instead of one per request
+// Requests still running when the deadline passes are cancelled and
+// get an Error with ErrBatchTimeout
+func ChainOfHttpGetCallsTimeout(urls []string, overall time.Duration) []Result {
+	ctx, cancel := context.WithTimeout(context.Background(), overall)
+	defer cancel()
+
+	results := make([]Result, len(urls))
+	ch := make(chan indexedResult, len(urls))
+	for i, url := range urls {
+		go func(i int, url string) {
+			ch <- indexedResult{index: i, result: HttpGetCallCtx(ctx, url)}
+		}(i, url)
+	}
+	for done := 0; done < len(urls); done++ {
+		select {
+		case r := <-ch:
+			results[r.index] = r.result
+			if _, isError := r.result.(errorValuer); isError && ctx.Err() != nil {
+				// cancelled by the deadline but received before ctx.Done
+				results[r.index] = Error[error]{Value: ErrBatchTimeout}
+			}
+		case <-ctx.Done():
+			for i := range results {
+				if results[i] == nil {
+					results[i] = Error[error]{Value: ErrBatchTimeout}
+				}
+			}
+			return results
+		}
+	}
+	return results
+}
+
+// Function that makes HTTP GET calls concurrently and returns as soon
+// as n successful bodies have been collected, cancelling the requests
+// that are still running and skipping the ones not yet dispatched
+// Bodies are returned in completion order, fewer than n bodies are
+// returned when not enough requests succeed
+// A concurrency lower than one means no limit
+func FirstNOk(urls []string, n int, concurrency int) []RequestBodyAsString {
+	if n <= 0 {
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	jobs := make(chan string)
+	results := make(chan Result, len(urls))
+	var wg sync.WaitGroup
+	for w := 0; w < workerCount(concurrency, len(urls)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for url := range jobs {
+				results <- HttpGetCallCtx(ctx, url)
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for _, url := range urls {
+			select {
+			case jobs <- url:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var bodies []RequestBodyAsString
+	for result := range results {
+		if ok, isOk := result.(Ok[RequestBodyAsString]); isOk {
+			bodies = append(bodies, ok.Value)
+			if len(bodies) == n {
+				break
+			}
+		}
+	}
+	return bodies
+}
+
+// Function that calls task for every index from 0 to n-1 with at most
+// concurrency calls running at the same time, and waits for all of them
+// A concurrency lower than one means no limit
+func forEachConcurrently(n, concurrency int, task func(i int)) {
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workerCount(concurrency, n); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				task(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// Function that calls fetch for every url with at most concurrency
+// calls running at the same time and returns the results in input order
+// A concurrency lower than one means no limit
+func chainWithConcurrency(urls []string, concurrency int, fetch func(url string) Result) []Result {
+	results := make([]Result, len(urls))
+	forEachConcurrently(len(urls), concurrency, func(i int) {
+		results[i] = fetch(urls[i])
+	})
+	return results
+}
+
+// Error used when a response has an unsuccessful status code
+// and the caller needs to treat it as a failure
+type StatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return "unexpected response status: " + e.Status
+}
+
+// Asynchronous function that makes an HTTP GET request with basic
+// authentication and sends the Result to the channel
+// Like AsyncHttpGetCall, any response is sent as an Ok with its body,
+// including a 401 Unauthorized when the credentials are rejected
+func AsyncHttpGetWithAuth(url, username, password string, ch chan<- Result) {
+	var once sync.Once
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		sendOnce(ch, &once, Error[error]{Value: err})
+		return
+	}
+	req.SetBasicAuth(username, password)
+	sendOnce(ch, &once, fetchResult(http.DefaultClient, req))
+}
+
+// Function that makes a chain of HTTP GET calls for a map of
+// name to URL and returns the Result of each call under its name,
+// so results can be addressed by a logical name instead of an index
+// A concurrency lower than one means no limit
+func ChainNamed(urls map[string]string, concurrency int) map[string]Result {
+	names := make([]string, 0, len(urls))
+	list := make([]string, 0, len(urls))
+	for name, url := range urls {
+		names = append(names, name)
+		list = append(list, url)
+	}
+	results := chainWithConcurrency(list, concurrency, httpGetResult)
+	named := make(map[string]Result, len(urls))
+	for i, name := range names {
+		named[name] = results[i]
+	}
+	return named
+}
+
+// Function that collects the URLs received from in and, once no new
+// URL has arrived for wait, makes the accumulated batch of HTTP GET
+// calls and sends their results in order on the returned channel
+// URLs arriving while a batch is being sent wait for the next batch,
+// when in is closed the pending URLs are dispatched and the returned
+// channel is closed
+// A concurrency lower than one means no limit
+func DebounceURLs(in <-chan string, wait time.Duration, concurrency int) <-chan Result {
+	out := make(chan Result)
+	go func() {
+		defer close(out)
+		var batch []string
+		flush := func() {
+			for _, result := range chainWithConcurrency(batch, concurrency, httpGetResult) {
+				out <- result
+			}
+			batch = nil
+		}
+		timer := time.NewTimer(wait)
+		timer.Stop()
+		for {
+			select {
+			case url, ok := <-in:
+				if !ok {
+					timer.Stop()
+					if len(batch) > 0 {
+						flush()
+					}
+					return
+				}
+				batch = append(batch, url)
+				timer.Reset(wait)
+			case <-timer.C:
+				flush()
+			}
+		}
+	}()
+	return out
+}
+
+// Function that adds the query parameters to a base URL, encoding
+// them correctly instead of concatenating strings, parameters already
+// present in the base are kept unless overwritten by params
+// Returns an error when the base is not an absolute URL
+func BuildURL(base string, params map[string]string) (string, error) {
+	u, err := neturl.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("invalid base URL %q: scheme and host are required", base)
+	}
+	query := u.Query()
+	for key, value := range params {
+		query.Set(key, value)
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// Function that returns the class of an HTTP status code,
+// "informational", "success", "redirect", "client_error" or
+// "server_error", and "unknown" for codes outside 100-599
+func ClassifyStatus(code int) string {
+	switch {
+	case code >= 100 && code < 200:
+		return "informational"
+	case code >= 200 && code < 300:
+		return "success"
+	case code >= 300 && code < 400:
+		return "redirect"
+	case code >= 400 && code < 500:
+		return "client_error"
+	case code >= 500 && code < 600:
+		return "server_error"
+	}
+	return "unknown"
+}
+
+// Structure with the full response of an HTTP call, including its
+// status code, the class of the status (see ClassifyStatus) and headers
+type HttpResponse struct {
+	StatusCode int
+	Class      string
+	Header     http.Header
+	Body       RequestBodyAsString
+}
+
+// Asynchronous function that makes an HTTP GET request like
+// AsyncHttpGetCall, but sends an Ok[HttpResponse] with the status
+// and headers of the response instead of only its body
+func AsyncHttpGetCallFull(params UrlAndChanelParams) {
+	p := params.(UrlAndChanel[string, chan<- Result])
+	if p.Ch == nil {
+		panic("AsyncHttpGetCallFull: nil result channel")
+	}
+	var once sync.Once
+	req, err := http.NewRequest(http.MethodGet, p.Url, nil)
+	if err != nil {
+		sendOnce(p.Ch, &once, Error[error]{Value: err})
+		return
+	}
+	resp, body, err := fetchBody(http.DefaultClient, req)
+	if err != nil {
+		sendOnce(p.Ch, &once, Error[error]{Value: err})
+		return
+	}
+	sendOnce(p.Ch, &once, Ok[HttpResponse]{Value: HttpResponse{
+		StatusCode: resp.StatusCode,
+		Class:      ClassifyStatus(resp.StatusCode),
+		Header:     resp.Header,
+		Body:       string(body),
+	}})
+}
+
+// Function that counts the results carrying an HttpResponse (as sent
+// by AsyncHttpGetCallFull) per status code, other results are ignored
+func StatusHistogram(results []Result) map[int]int {
+	histogram := make(map[int]int)
+	for _, result := range results {
+		if resp, ok := result.(Ok[HttpResponse]); ok {
+			histogram[resp.Value.StatusCode]++
+		}
+	}
+	return histogram
+}
+
+// Function that makes a chain of HTTP GET calls and returns the
+// results both in input order and indexed by URL
+// A URL present more than once is fetched every time, all its
+// results are in ordered and the map keeps the last one
+// A concurrency lower than one means no limit
+func ChainIndexed(urls []string, concurrency int) (ordered []Result, byURL map[string]Result) {
+	ordered = chainWithConcurrency(urls, concurrency, httpGetResult)
+	byURL = make(map[string]Result, len(urls))
+	for i, url := range urls {
+		byURL[url] = ordered[i]
+	}
+	return ordered, byURL
+}
+
+// Function that checks concurrently whether each URL is reachable with
+// a lightweight HEAD request, so dead URLs can be pruned before a full
+// fetch, any response counts as reachable and its Ok holds the status
+// A request not answered within timeout gets an Error
+func CheckReachable(urls []string, timeout time.Duration) []Result {
+	client := &http.Client{Timeout: timeout}
+	return chainWithConcurrency(urls, 0, func(url string) Result {
+		req, err := http.NewRequest(http.MethodHead, url, nil)
+		if err != nil {
+			return Error[error]{Value: err}
+		}
+		resp, _, err := fetchBody(client, req)
+		if err != nil {
+			return Error[error]{Value: err}
+		}
+		return Ok[RequestBodyAsString]{Value: resp.Status}
+	})
+}
+
+// Function that makes a chain of HTTP GET calls where the first Error
+// cancels every request still in flight or not yet sent, for
+// all-or-nothing workflows, the cancelled requests get an Error with
+// the cancellation error (context.Canceled) in the returned results
+// A concurrency lower than one means no limit
+func ChainFailFast(ctx context.Context, urls []string, concurrency int) []Result {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	return chainWithConcurrency(urls, concurrency, func(url string) Result {
+		if err := ctx.Err(); err != nil {
+			return Error[error]{Value: err}
+		}
+		result := HttpGetCallCtx(ctx, url)
+		if _, isError := result.(errorValuer); isError {
+			cancel()
+		}
+		return result
+	})
+}
+
+// Function that races HTTP GET calls to redundant URLs and returns the
+// first Ok, cancelling the requests that are still running, when all
+// of them fail the last Error received is returned
+func GetFastest(urls []string) Result {
+	if len(urls) == 0 {
+		return Error[error]{Value: errors.New("no URLs to race")}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch := make(chan Result, len(urls))
+	for _, url := range urls {
+		go func(url string) {
+			ch <- HttpGetCallCtx(ctx, url)
+		}(url)
+	}
+	var last Result
+	for range urls {
+		last = <-ch
+		if _, isOk := last.(okValuer); isOk {
+			return last
+		}
+	}
+	return last
+}
+
+// Function that follows a paginated API, it fetches startURL and asks
+// nextFn for the URL of the next page from each body, until nextFn
+// returns false, the Result of every page is returned in order
+// An Error stops the pagination and is the last returned Result, a
+// next URL that was already fetched also stops it with an Error, so
+// an API linking back to a previous page does not loop forever
+func FollowPages(startURL string, nextFn func(body RequestBodyAsString) (string, bool)) []Result {
+	return FollowPagesN(startURL, nextFn, 0)
+}
+
+// Function that follows a paginated API like FollowPages, but stops
+// after maxPages pages even if nextFn returns more
+// A maxPages lower than one means no limit
+func FollowPagesN(startURL string, nextFn func(body RequestBodyAsString) (string, bool), maxPages int) []Result {
+	var results []Result
+	visited := make(map[string]bool)
+	url := startURL
+	for {
+		visited[url] = true
+		result := httpGetResult(url)
+		results = append(results, result)
+		page, isOk := result.(Ok[RequestBodyAsString])
+		if !isOk || len(results) == maxPages {
+			return results
+		}
+		next, more := nextFn(page.Value)
+		if !more {
+			return results
+		}
+		if visited[next] {
+			return append(results, Error[error]{Value: fmt.Errorf("pagination loop: %s was already fetched", next)})
+		}
+		url = next
+	}
+}
+
+// Error used for the responses that would make a batch go over its
+// byte budget
+var ErrByteBudgetExceeded = errors.New("batch byte budget exceeded")
+
+// Function that reads a body taking every chunk from a shared budget
+// of bytes, used holds the bytes taken so far by the whole batch
+// It fails with ErrByteBudgetExceeded when the body has more bytes
+// than the budget has left, the bytes of a body that is not returned
+// are given back to the budget, so it only counts the kept bodies
+func readWithByteBudget(r io.Reader, used *atomic.Int64, maxTotalBytes int64) ([]byte, error) {
+	var body []byte
+	buf := make([]byte, 32*1024)
+	for {
+		// reserve a chunk of the budget before reading into it
+		var chunk int64
+		for {
+			current := used.Load()
+			chunk = min(int64(len(buf)), maxTotalBytes-current)
+			if chunk <= 0 {
+				chunk = 0
+				break
+			}
+			if used.CompareAndSwap(current, current+chunk) {
+				break
+			}
+		}
+		if chunk == 0 {
+			// the budget is exhausted, the body fits only if it has ended
+			n, err := r.Read(buf[:1])
+			switch {
+			case n > 0 || err == nil:
+				used.Add(-int64(len(body)))
+				return nil, ErrByteBudgetExceeded
+			case err == io.EOF:
+				return body, nil
+			default:
+				used.Add(-int64(len(body)))
+				return nil, err
+			}
+		}
+		n, err := r.Read(buf[:chunk])
+		used.Add(int64(n) - chunk)
+		body = append(body, buf[:n]...)
+		if err == io.EOF {
+			return body, nil
+		}
+		if err != nil {
+			used.Add(-int64(len(body)))
+			return nil, err
+		}
+	}
+}
+
+// Function that makes a chain of HTTP GET calls that stops accepting
+// response bytes once the bodies of the whole batch reach
+// maxTotalBytes, the requests whose body would go over the budget get
+// an Error with ErrByteBudgetExceeded, protecting memory in bulk downloads
+// A concurrency lower than one means no limit
+func ChainWithByteBudget(urls []string, maxTotalBytes int64, concurrency int) []Result {
+	var used atomic.Int64
+	return chainWithConcurrency(urls, concurrency, func(url string) Result {
+		resp, err := http.Get(url)
+		if err != nil {
+			return Error[error]{Value: err}
+		}
+		defer resp.Body.Close()
+		reader, err := decodeBody(resp)
+		if err != nil {
+			return Error[error]{Value: err}
+		}
+		body, err := readWithByteBudget(reader, &used, maxTotalBytes)
+		if err != nil {
+			return Error[error]{Value: err}
+		}
+		return Ok[RequestBodyAsString]{Value: string(body)}
+	})
+}
@@ -0,0 +1,497 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Starts a test server that answers every request with its URL path
+func newPathServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.URL.Path)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestChainWithProgress(t *testing.T) {
+	server := newPathServer(t)
+	urls := []string{server.URL + "/a", server.URL + "/b", server.URL + "/c"}
+	var calls []int
+	results := ChainWithProgress(urls, func(done, total int) {
+		if total != len(urls) {
+			t.Errorf("total = %d, want %d", total, len(urls))
+		}
+		calls = append(calls, done)
+	})
+	if len(calls) != len(urls) {
+		t.Fatalf("onProgress called %d times, want %d", len(calls), len(urls))
+	}
+	for i, done := range calls {
+		if done != i+1 {
+			t.Errorf("call %d reported done = %d, want %d", i, done, i+1)
+		}
+	}
+	if results[1] != (Ok[RequestBodyAsString]{Value: "/b"}) {
+		t.Errorf("results[1] = %v, want Ok{/b}", results[1])
+	}
+}
+
+func TestHttpGetDecodesGzipBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		switch r.URL.Path {
+		case "/empty":
+		case "/no-content":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			zw := gzip.NewWriter(w)
+			zw.Write([]byte("hello"))
+			zw.Close()
+		}
+	}))
+	defer server.Close()
+
+	// ask for gzip explicitly so the transport does not decompress it
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	if got := fetchResult(http.DefaultClient, req); got != (Ok[RequestBodyAsString]{Value: "hello"}) {
+		t.Errorf("gzipped body = %v, want Ok{hello}", got)
+	}
+	for _, path := range []string{"/empty", "/no-content"} {
+		if got := httpGetResult(server.URL + path); got != (Ok[RequestBodyAsString]{}) {
+			t.Errorf("%s = %v, want an empty Ok", path, got)
+		}
+	}
+}
+
+func TestChainKeyedPool(t *testing.T) {
+	var mu sync.Mutex
+	var inFlight, maxInFlight int
+	order := make(map[string][]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		maxInFlight = max(maxInFlight, inFlight)
+		key := r.URL.Query().Get("key")
+		order[key] = append(order[key], r.URL.Path)
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	var reqs []*http.Request
+	for i := 0; i < 6; i++ {
+		key := []string{"a", "b"}[i%2]
+		req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/%d?key=%s", server.URL, i, key), nil)
+		reqs = append(reqs, req)
+	}
+	ChainKeyedPool(reqs, func(r *http.Request) string { return r.URL.Query().Get("key") }, 2)
+
+	want := map[string][]string{"a": {"/0", "/2", "/4"}, "b": {"/1", "/3", "/5"}}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("per-key order = %v, want %v", order, want)
+	}
+	if maxInFlight != 2 {
+		t.Errorf("max requests in flight = %d, want 2", maxInFlight)
+	}
+}
+
+func TestChainOfHttpGetCallsTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+			return
+		}
+		fmt.Fprint(w, "fast")
+	}))
+	defer server.Close()
+
+	results := ChainOfHttpGetCallsTimeout([]string{server.URL + "/fast", server.URL + "/slow"}, 200*time.Millisecond)
+	if results[0] != (Ok[RequestBodyAsString]{Value: "fast"}) {
+		t.Errorf("results[0] = %v, want Ok{fast}", results[0])
+	}
+	if results[1] != (Error[error]{Value: ErrBatchTimeout}) {
+		t.Errorf("results[1] = %v, want Error{ErrBatchTimeout}", results[1])
+	}
+}
+
+func TestFirstNOk(t *testing.T) {
+	slowStarted, slowCancelled := make(chan struct{}), make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			close(slowStarted)
+			<-r.Context().Done()
+			close(slowCancelled)
+			return
+		}
+		<-slowStarted
+		fmt.Fprint(w, "ok")
+	}))
+	defer server.Close()
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	urls := []string{closed.URL, server.URL + "/a", server.URL + "/b", server.URL + "/slow"}
+	bodies := FirstNOk(urls, 2, 0)
+	if !reflect.DeepEqual(bodies, []RequestBodyAsString{"ok", "ok"}) {
+		t.Errorf("FirstNOk = %v, want [ok ok]", bodies)
+	}
+	select {
+	case <-slowCancelled:
+	case <-time.After(time.Second):
+		t.Error("the remaining request was not cancelled")
+	}
+}
+
+func TestAsyncHttpGetWithAuth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != "secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, "welcome")
+	}))
+	defer server.Close()
+
+	ch := make(chan Result, 2)
+	AsyncHttpGetWithAuth(server.URL, "user", "secret", ch)
+	AsyncHttpGetWithAuth(server.URL, "user", "wrong", ch)
+	if got := <-ch; got != (Ok[RequestBodyAsString]{Value: "welcome"}) {
+		t.Errorf("authed request = %v, want Ok{welcome}", got)
+	}
+	if got := <-ch; got != (Ok[RequestBodyAsString]{Value: "unauthorized\n"}) {
+		t.Errorf("request with a wrong password = %v, want the 401 body", got)
+	}
+}
+
+func TestChainNamed(t *testing.T) {
+	server := newPathServer(t)
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	results := ChainNamed(map[string]string{
+		"users":  server.URL + "/users",
+		"orders": server.URL + "/orders",
+		"down":   closed.URL,
+	}, 2)
+	if results["users"] != (Ok[RequestBodyAsString]{Value: "/users"}) {
+		t.Errorf(`results["users"] = %v, want Ok{/users}`, results["users"])
+	}
+	if results["orders"] != (Ok[RequestBodyAsString]{Value: "/orders"}) {
+		t.Errorf(`results["orders"] = %v, want Ok{/orders}`, results["orders"])
+	}
+	if _, isErr := results["down"].(Error[error]); !isErr {
+		t.Errorf(`results["down"] = %v, want an Error`, results["down"])
+	}
+}
+
+func TestHttpGetCallCtxLogs(t *testing.T) {
+	server := newPathServer(t)
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	var buf bytes.Buffer
+	ctx := ContextWithLogger(context.Background(), slog.New(slog.NewTextHandler(&buf, nil)))
+	HttpGetCallCtx(ctx, server.URL+"/ok")
+	HttpGetCallCtx(ctx, closed.URL)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	want := []string{`msg="request start"`, `msg="request finish"`, `msg="request start"`, `msg="request error"`}
+	if len(lines) != len(want) {
+		t.Fatalf("logged %d lines, want %d:\n%s", len(lines), len(want), buf.String())
+	}
+	for i, line := range lines {
+		if !strings.Contains(line, want[i]) {
+			t.Errorf("log line %d = %s, want it to contain %s", i, line, want[i])
+		}
+	}
+	if !strings.Contains(lines[1], "status=200") {
+		t.Errorf("finish line %s does not carry the status", lines[1])
+	}
+}
+
+func TestDebounceURLs(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		fmt.Fprint(w, r.URL.Path)
+	}))
+	defer server.Close()
+
+	in := make(chan string)
+	out := DebounceURLs(in, 100*time.Millisecond, 0)
+	in <- server.URL + "/a"
+	time.Sleep(20 * time.Millisecond)
+	in <- server.URL + "/b"
+	if n := hits.Load(); n != 0 {
+		t.Errorf("%d requests made before the pause, want 0", n)
+	}
+	for _, want := range []string{"/a", "/b"} {
+		if got := <-out; got != (Ok[RequestBodyAsString]{Value: want}) {
+			t.Errorf("first batch result = %v, want Ok{%s}", got, want)
+		}
+	}
+	if n := hits.Load(); n != 2 {
+		t.Errorf("first batch made %d requests, want 2", n)
+	}
+	in <- server.URL + "/c"
+	close(in)
+	if got := <-out; got != (Ok[RequestBodyAsString]{Value: "/c"}) {
+		t.Errorf("second batch result = %v, want Ok{/c}", got)
+	}
+	if _, open := <-out; open {
+		t.Error("output channel not closed after the input was closed")
+	}
+}
+
+func TestBuildURL(t *testing.T) {
+	got, err := BuildURL("https://example.com/search?page=1", map[string]string{"q": "a b&c=d", "lang": "ñ"})
+	if want := "https://example.com/search?lang=%C3%B1&page=1&q=a+b%26c%3Dd"; got != want || err != nil {
+		t.Errorf("BuildURL = (%q, %v), want (%q, <nil>)", got, err, want)
+	}
+	for _, base := range []string{"://bad", "/relative/path"} {
+		if _, err := BuildURL(base, nil); err == nil {
+			t.Errorf("BuildURL(%q) did not return an error", base)
+		}
+	}
+}
+
+func TestClassifyStatus(t *testing.T) {
+	tests := []struct {
+		code int
+		want string
+	}{
+		{199, "informational"},
+		{200, "success"},
+		{299, "success"},
+		{300, "redirect"},
+		{399, "redirect"},
+		{400, "client_error"},
+		{499, "client_error"},
+		{500, "server_error"},
+		{599, "server_error"},
+	}
+	for _, tt := range tests {
+		if got := ClassifyStatus(tt.code); got != tt.want {
+			t.Errorf("ClassifyStatus(%d) = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestChainIndexed(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s#%d", r.URL.Path, hits.Add(1))
+	}))
+	defer server.Close()
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	urls := []string{server.URL + "/a", closed.URL, server.URL + "/a"}
+	ordered, byURL := ChainIndexed(urls, 1)
+	if len(ordered) != 3 || ordered[0] != (Ok[RequestBodyAsString]{Value: "/a#1"}) || ordered[2] != (Ok[RequestBodyAsString]{Value: "/a#2"}) {
+		t.Errorf("ordered = %v, want both fetches of /a", ordered)
+	}
+	if _, isErr := ordered[1].(Error[error]); !isErr {
+		t.Errorf("ordered[1] = %v, want an Error", ordered[1])
+	}
+	if len(byURL) != 2 || byURL[urls[0]] != ordered[2] || byURL[closed.URL] != ordered[1] {
+		t.Errorf("byURL = %v, want the last result of each URL", byURL)
+	}
+}
+
+func TestCheckReachable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+	}))
+	defer server.Close()
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	results := CheckReachable([]string{server.URL, closed.URL}, time.Second)
+	if results[0] != (Ok[RequestBodyAsString]{Value: "200 OK"}) {
+		t.Errorf("results[0] = %v, want Ok{200 OK}", results[0])
+	}
+	if _, isErr := results[1].(Error[error]); !isErr {
+		t.Errorf("results[1] = %v, want an Error", results[1])
+	}
+}
+
+func TestChainFailFast(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	start := time.Now()
+	results := ChainFailFast(context.Background(), []string{server.URL + "/a", closed.URL, server.URL + "/b"}, 0)
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("ChainFailFast took %v, want the slow requests cancelled", elapsed)
+	}
+	for _, i := range []int{0, 2} {
+		if err := resultError(results[i]); !errors.Is(err, context.Canceled) {
+			t.Errorf("results[%d] = %v, want an Error with context.Canceled", i, results[i])
+		}
+	}
+	if err := resultError(results[1]); err == nil || errors.Is(err, context.Canceled) {
+		t.Errorf("results[1] = %v, want the original connection Error", results[1])
+	}
+}
+
+func TestKleisliResultFetchThenValidate(t *testing.T) {
+	server := newPathServer(t)
+	errEmpty := errors.New("empty body")
+	validate := func(body RequestBodyAsString) Result {
+		if body == "/" {
+			return Error[error]{Value: errEmpty}
+		}
+		return Ok[RequestBodyAsString]{Value: body}
+	}
+	fetchAndValidate := KleisliResult[string, RequestBodyAsString, RequestBodyAsString](httpGetResult, validate)
+
+	if got := fetchAndValidate(server.URL + "/data"); got != (Ok[RequestBodyAsString]{Value: "/data"}) {
+		t.Errorf("fetchAndValidate(/data) = %v, want Ok{/data}", got)
+	}
+	if got := fetchAndValidate(server.URL + "/"); got != (Error[error]{Value: errEmpty}) {
+		t.Errorf("fetchAndValidate(/) = %v, want Error{empty body}", got)
+	}
+	if _, isErr := fetchAndValidate("http://127.0.0.1:1").(Error[error]); !isErr {
+		t.Error("a failed fetch did not short-circuit the validation")
+	}
+}
+
+func TestStatusHistogram(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		code, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/"))
+		w.WriteHeader(code)
+	}))
+	defer server.Close()
+
+	codes := []string{"200", "404", "200", "500", "200", "404"}
+	ch := make(chan Result, len(codes)+1)
+	for _, code := range codes {
+		AsyncHttpGetCallFull(UrlAndChanel[string, chan<- Result]{Url: server.URL + "/" + code, Ch: ch})
+	}
+	ch <- Error[error]{Value: errors.New("ignored")}
+	close(ch)
+	var results []Result
+	for result := range ch {
+		results = append(results, result)
+	}
+	want := map[int]int{200: 3, 404: 2, 500: 1}
+	if got := StatusHistogram(results); !reflect.DeepEqual(got, want) {
+		t.Errorf("StatusHistogram = %v, want %v", got, want)
+	}
+}
+
+func TestGetFastest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(time.Second):
+			}
+		}
+		fmt.Fprint(w, r.URL.Path)
+	}))
+	defer server.Close()
+
+	if got := GetFastest([]string{server.URL + "/slow", server.URL + "/fast"}); got != (Ok[RequestBodyAsString]{Value: "/fast"}) {
+		t.Errorf("GetFastest = %v, want Ok{/fast}", got)
+	}
+}
+
+func TestFollowPages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next := map[string]string{"/1": "/2", "/2": "/3", "/loop": "/loop"}[r.URL.Path]
+		fmt.Fprintf(w, "%s>%s", r.URL.Path, next)
+	}))
+	defer server.Close()
+	nextFn := func(body RequestBodyAsString) (string, bool) {
+		_, next, _ := strings.Cut(body, ">")
+		return server.URL + next, next != ""
+	}
+
+	got := FollowPages(server.URL+"/1", nextFn)
+	want := []Result{
+		Ok[RequestBodyAsString]{Value: "/1>/2"},
+		Ok[RequestBodyAsString]{Value: "/2>/3"},
+		Ok[RequestBodyAsString]{Value: "/3>"},
+	}
+	if !ResultsEqual(got, want) {
+		t.Errorf("FollowPages = %v, want %v", got, want)
+	}
+	if got := FollowPagesN(server.URL+"/1", nextFn, 2); !ResultsEqual(got, want[:2]) {
+		t.Errorf("FollowPagesN with 2 max pages = %v, want %v", got, want[:2])
+	}
+	got = FollowPages(server.URL+"/loop", nextFn)
+	if len(got) != 2 {
+		t.Fatalf("FollowPages over a loop returned %d results, want 2", len(got))
+	}
+	if _, isErr := got[1].(Error[error]); !isErr {
+		t.Errorf("FollowPages over a loop ended with %v, want an Error", got[1])
+	}
+}
+
+func TestReadWithByteBudgetRefunds(t *testing.T) {
+	var used atomic.Int64
+	if _, err := readWithByteBudget(strings.NewReader(strings.Repeat("x", 200)), &used, 150); err != ErrByteBudgetExceeded {
+		t.Fatalf("reading over the budget returned %v, want ErrByteBudgetExceeded", err)
+	}
+	if used.Load() != 0 {
+		t.Errorf("used = %d after a rejected body, want 0", used.Load())
+	}
+	if body, err := readWithByteBudget(strings.NewReader(strings.Repeat("x", 100)), &used, 150); len(body) != 100 || err != nil {
+		t.Errorf("reading within the budget = (%d bytes, %v), want (100 bytes, <nil>)", len(body), err)
+	}
+}
+
+func TestChainWithByteBudget(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, strings.Repeat("x", 100))
+	}))
+	defer server.Close()
+
+	urls := []string{server.URL, server.URL, server.URL, server.URL, server.URL}
+	var kept int
+	for _, result := range ChainWithByteBudget(urls, 250, 1) {
+		switch r := result.(type) {
+		case Ok[RequestBodyAsString]:
+			kept += len(r.Value)
+		case Error[error]:
+			if r.Value != ErrByteBudgetExceeded {
+				t.Errorf("unexpected Error %v", r.Value)
+			}
+		}
+	}
+	if kept != 200 {
+		t.Errorf("kept %d body bytes, want 200 within a budget of 250", kept)
+	}
+}
@@ -1,8 +1,9 @@
 package main
 
 import (
+	"fmt"
 	"io"
-	"net/http"
+	"os"
 	"sync"
 )
 
@@ -37,6 +38,18 @@ type Error[U any] struct {
 func (Ok[T]) isResult()    {}
 func (Error[U]) isResult() {}
 
+// Unexported accessors used to reach the value of any Ok or Error
+// without knowing its type parameter
+func (o Ok[T]) okValue() any       { return o.Value }
+func (e Error[U]) errorValue() any { return e.Value }
+
+type okValuer interface {
+	okValue() any
+}
+type errorValuer interface {
+	errorValue() any
+}
+
 /* ************************************** */
 
 // Example of using the Result monad implemented in Go
@@ -55,6 +68,18 @@ type UrlAndChanel[T string, U chan<- Result] struct {
 // Implementation of the UrlAndChanelParams interface
 func (UrlAndChanel[T, U]) isUrlAndChanelParams() {}
 
+// Function to create the parameters of the AsyncHttpGetCall function
+// without spelling out the generic instantiation at the call site
+func NewUrlAndChanel(url string, ch chan<- Result) UrlAndChanel[string, chan<- Result] {
+	return UrlAndChanel[string, chan<- Result]{Url: url, Ch: ch}
+}
+
+// Function that starts AsyncHttpGetCall in a new goroutine with these
+// parameters, a single Result is sent to the channel
+func (p UrlAndChanel[T, U]) Dispatch() {
+	go AsyncHttpGetCall(p)
+}
+
 // Alias for the RequestBodyAsString data type, which is a string
 type RequestBodyAsString = string
 
@@ -63,23 +88,18 @@ type RequestBodyAsString = string
 // Receives a structure that contains the URL and a channel to send the result
 // The function sends the result to the channel
 // If an error occurs, it sends an error message to the channel
-// The channel is closed at the end of the function
+// Exactly one Result is sent, so the call never blocks on a second
+// send even when the channel is unbuffered
+// A nil channel panics immediately instead of blocking forever on the send
 func AsyncHttpGetCall(params UrlAndChanelParams) {
 	p := params.(UrlAndChanel[string, chan<- Result])
 	url := p.Url
 	ch := p.Ch
-	resp, err := http.Get(url)
-	if err != nil {
-		ch <- Error[error]{Value: err}
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		ch <- Error[error]{Value: err}
+	if ch == nil {
+		panic("AsyncHttpGetCall: nil result channel")
 	}
-
-	ch <- Ok[RequestBodyAsString]{Value: string(body)}
+	var once sync.Once
+	sendOnce(ch, &once, httpGetResult(url))
 }
 
 // Function that makes a chain of HTTP GET calls asynchronously
@@ -97,6 +117,24 @@ func AsyncChainOfHttpGetCalls(urls []string) []Result {
 	return results
 }
 
+// Function that makes a chain of HTTP GET calls asynchronously and
+// returns as soon as n results (Ok or Error) have arrived, in arrival
+// order, the remaining calls are left running and their results are
+// discarded (best-effort), all the results are returned when n is
+// larger than the number of URLs
+func AsyncChainFirstN(urls []string, n int) []Result {
+	n = max(min(n, len(urls)), 0)
+	results := make([]Result, n)
+	ch := make(chan Result, len(urls))
+	for _, url := range urls {
+		go AsyncHttpGetCall(NewUrlAndChanel(url, ch))
+	}
+	for i := 0; i < n; i++ {
+		results[i] = <-ch
+	}
+	return results
+}
+
 // Function that makes a chain of HTTP GET calls synchronously
 // using the AsyncHttpGetCall function
 // The function returns a slice of Result
@@ -144,6 +182,37 @@ func UnpackResults(results []Result) ([]RequestBodyAsString, []error) {
 	return bodyRequestResults, bodyRequestErrors
 }
 
+// Function that zips the two slices returned by UnpackResults back
+// into one record per request, so callers do not have to index both
+// slices in lockstep, extra elements of the longer slice are ignored
+func PairResults(bodies []RequestBodyAsString, errs []error) []struct {
+	Body RequestBodyAsString
+	Err  error
+} {
+	pairs := make([]struct {
+		Body RequestBodyAsString
+		Err  error
+	}, min(len(bodies), len(errs)))
+	for i := range pairs {
+		pairs[i].Body = bodies[i]
+		pairs[i].Err = errs[i]
+	}
+	return pairs
+}
+
+// Function that writes the body of every Ok result to out and the
+// message of every Error result to errOut, one per line
+func PrintResults(results []Result, out io.Writer, errOut io.Writer) {
+	for _, result := range results {
+		switch result := result.(type) {
+		case okValuer:
+			fmt.Fprintln(out, result.okValue())
+		case errorValuer:
+			fmt.Fprintln(errOut, "Error:", errorMessage(result.errorValue()))
+		}
+	}
+}
+
 func main() {
 
 	urls := []string{
@@ -153,39 +222,20 @@ func main() {
 	}
 
 	// Example of using the SyncChainOfHttpGetCalls function
+	// consider that UnpackResults can be used to split the
+	// results into bodies and errors if they need more processing
 	resultsSyncChainOfHttpGetCalls := SyncChainOfHttpGetCalls(urls)
-	bodyRequestResults, bodyRequestErrors := UnpackResults(resultsSyncChainOfHttpGetCalls)
-	for i, bodyRequestResult := range bodyRequestResults {
-		if bodyRequestErrors[i] != nil {
-			println("Error:", bodyRequestErrors[i])
-		} else {
-			println(bodyRequestResult)
-		}
-	}
+	PrintResults(resultsSyncChainOfHttpGetCalls, os.Stdout, os.Stderr)
 
 	// Example of using the AsyncChainOfHttpGetCalls function
-	// consider that this function can also use
-	// UnpackResults if no additional processing is required
 	resultsAsyncChainOfHttpGetCalls := AsyncChainOfHttpGetCalls(urls)
-	for _, result := range resultsAsyncChainOfHttpGetCalls {
-		switch result := result.(type) {
-		case Ok[RequestBodyAsString]:
-			println(result.Value)
-		case Error[error]:
-			println("Error:", result.Value)
-		}
-	}
+	PrintResults(resultsAsyncChainOfHttpGetCalls, os.Stdout, os.Stderr)
 
 	// Example of using the AsyncHttpGetCall function
 	resultAsyncHttpGetCall := make(chan Result)
 	params := UrlAndChanel[string, chan<- Result]{Url: "https://api.chucknorris.io/jokes/random", Ch: resultAsyncHttpGetCall}
 	go AsyncHttpGetCall(params)
 	result := <-resultAsyncHttpGetCall
-	switch result := result.(type) {
-	case Ok[RequestBodyAsString]:
-		println(result.Value)
-	case Error[error]:
-		println("Error:", result.Value)
-	}
+	PrintResults([]Result{result}, os.Stdout, os.Stderr)
 
 }
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAsyncHttpGetCallNilChannelPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("AsyncHttpGetCall with a nil channel did not panic")
+		}
+	}()
+	AsyncHttpGetCall(UrlAndChanel[string, chan<- Result]{Url: "http://127.0.0.1:1", Ch: nil})
+}
+
+func TestPairResults(t *testing.T) {
+	errA := errors.New("a")
+	pairs := PairResults(UnpackResults([]Result{
+		Ok[RequestBodyAsString]{Value: "x"},
+		Error[error]{Value: errA},
+	}))
+	if len(pairs) != 2 {
+		t.Fatalf("PairResults returned %d pairs, want 2", len(pairs))
+	}
+	if pairs[0].Body != "x" || pairs[0].Err != nil {
+		t.Errorf("pairs[0] = %+v, want {x <nil>}", pairs[0])
+	}
+	if pairs[1].Body != "" || pairs[1].Err != errA {
+		t.Errorf("pairs[1] = %+v, want {\"\" a}", pairs[1])
+	}
+}
+
+func TestPrintResults(t *testing.T) {
+	var out, errOut bytes.Buffer
+	PrintResults([]Result{
+		Ok[RequestBodyAsString]{Value: "body"},
+		Error[error]{Value: errors.New("boom")},
+	}, &out, &errOut)
+	if out.String() != "body\n" {
+		t.Errorf("out = %q, want %q", out.String(), "body\n")
+	}
+	if errOut.String() != "Error: boom\n" {
+		t.Errorf("errOut = %q, want %q", errOut.String(), "Error: boom\n")
+	}
+}
+
+func TestAsyncHttpGetCallUnbufferedSendsOnce(t *testing.T) {
+	ch := make(chan Result)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		AsyncHttpGetCall(UrlAndChanel[string, chan<- Result]{Url: "http://127.0.0.1:1", Ch: ch})
+	}()
+	select {
+	case result := <-ch:
+		if _, isErr := result.(Error[error]); !isErr {
+			t.Errorf("result = %v, want an Error", result)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no result received")
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("AsyncHttpGetCall is still blocked after its result was received")
+	}
+}
+
+func TestNewUrlAndChanelDispatch(t *testing.T) {
+	server := newPathServer(t)
+	ch := make(chan Result)
+	params := NewUrlAndChanel(server.URL+"/a", ch)
+	if params.Url != server.URL+"/a" || params.Ch != ch {
+		t.Errorf("NewUrlAndChanel = %+v, want the given url and channel", params)
+	}
+	params.Dispatch()
+	if got := <-ch; got != (Ok[RequestBodyAsString]{Value: "/a"}) {
+		t.Errorf("Dispatch sent %v, want Ok{/a}", got)
+	}
+	select {
+	case extra := <-ch:
+		t.Errorf("Dispatch sent a second result %v", extra)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestAsyncChainFirstN(t *testing.T) {
+	server := newPathServer(t)
+	urls := []string{server.URL + "/a", server.URL + "/b", "http://127.0.0.1:1", server.URL + "/c"}
+	if got := AsyncChainFirstN(urls, 2); len(got) != 2 {
+		t.Errorf("AsyncChainFirstN returned %d results, want 2", len(got))
+	}
+	if got := AsyncChainFirstN(urls, 10); len(got) != len(urls) {
+		t.Errorf("AsyncChainFirstN with n over the URL count returned %d results, want %d", len(got), len(urls))
+	}
+}
+
+func TestDispatchFunctionsSendOnce(t *testing.T) {
+	server := newPathServer(t)
+	dispatchers := map[string]func(url string, ch chan<- Result){
+		"AsyncHttpGetCall": func(url string, ch chan<- Result) {
+			AsyncHttpGetCall(NewUrlAndChanel(url, ch))
+		},
+		"AsyncHttpGetCallFull": func(url string, ch chan<- Result) {
+			AsyncHttpGetCallFull(NewUrlAndChanel(url, ch))
+		},
+		"AsyncHttpGetWithAuth": func(url string, ch chan<- Result) {
+			AsyncHttpGetWithAuth(url, "user", "secret", ch)
+		},
+	}
+	for name, dispatch := range dispatchers {
+		for _, url := range []string{server.URL, "http://127.0.0.1:1", "://bad"} {
+			ch := make(chan Result)
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				dispatch(url, ch)
+			}()
+			<-ch
+			select {
+			case <-done:
+			case extra := <-ch:
+				t.Errorf("%s(%q) sent a second result %v", name, url, extra)
+			case <-time.After(time.Second):
+				t.Errorf("%s(%q) did not return after sending its result", name, url)
+			}
+		}
+	}
+}
@@ -1,9 +1,18 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
 )
 
 /*
@@ -53,6 +62,113 @@ func (io IO[A]) Run() A {
 	return io.run()
 }
 
+// Run function that executes the operation in a goroutine and returns
+// early with the context error when the context ends before it finishes
+// Go cannot stop a running function, so an action that does not watch
+// the context keeps running in the background after RunCtx returns
+func (io IO[A]) RunCtx(ctx context.Context) (A, error) {
+	done := make(chan A, 1)
+	go func() {
+		done <- io.run()
+	}()
+	select {
+	case value := <-done:
+		return value, nil
+	case <-ctx.Done():
+		var zero A
+		return zero, ctx.Err()
+	}
+}
+
+// Function that re-runs an IO action while isEmpty reports its
+// result as unsatisfactory, up to attempts runs in total
+// The action always runs at least once and the last result is returned
+func RetryIO[A any](io IO[A], isEmpty func(A) bool, attempts int) IO[A] {
+	return IO[A]{run: func() A {
+		result := io.run()
+		for i := 1; i < attempts && isEmpty(result); i++ {
+			result = io.run()
+		}
+		return result
+	}}
+}
+
+// Function that runs two IO actions one after the other
+// and pairs their results
+func JoinIO[A any, B any](a IO[A], b IO[B]) IO[struct {
+	First  A
+	Second B
+}] {
+	return IO[struct {
+		First  A
+		Second B
+	}]{run: func() struct {
+		First  A
+		Second B
+	} {
+		first := a.run()
+		second := b.run()
+		return struct {
+			First  A
+			Second B
+		}{First: first, Second: second}
+	}}
+}
+
+// Function that runs first only for its effect, discards its
+// result and then runs second, in Haskell its operator is (>>)
+func ThenIO[A any, B any](first IO[A], second IO[B]) IO[B] {
+	return IO[B]{run: func() B {
+		first.run()
+		return second.run()
+	}}
+}
+
+// Function that runs an IO action every interval in its own goroutine
+// and sends each result on the returned channel, until stop is closed
+// The channel is closed after stopping, a result that is not received
+// before stop is closed is dropped
+func ScheduleIO[A any](io IO[A], interval time.Duration, stop <-chan struct{}) <-chan A {
+	out := make(chan A)
+	go func() {
+		defer close(out)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+			select {
+			case out <- io.run():
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Function that waits d before running an IO action,
+// useful to simulate latency in IO pipelines
+func DelayIO[A any](io IO[A], d time.Duration) IO[A] {
+	return IO[A]{run: func() A {
+		time.Sleep(d)
+		return io.run()
+	}}
+}
+
+// Function that runs an IO action while holding mu, so actions that
+// touch shared state can be serialized compositionally
+func WithLockIO[A any](mu *sync.Mutex, io IO[A]) IO[A] {
+	return IO[A]{run: func() A {
+		mu.Lock()
+		defer mu.Unlock()
+		return io.run()
+	}}
+}
+
 /*
    Examples of IO Monad implementation
 */
@@ -76,6 +192,72 @@ func Println(message string) IO[string] {
 	}}
 }
 
+// Example 3: Function to encapsulate the read of an environment
+// variable, returns Ok with its value or Error when it is not set
+func GetEnv(key string) IO[Result] {
+	return IO[Result]{run: func() Result {
+		value, ok := os.LookupEnv(key)
+		if !ok {
+			return Error[error]{Value: fmt.Errorf("environment variable %q is not set", key)}
+		}
+		return Ok[RequestBodyAsString]{Value: value}
+	}}
+}
+
+// Example 4: Function to encapsulate the execution of an external
+// command, returns Ok with its standard output or Error with the
+// exit error and the standard error of the command
+func RunCommand(name string, args ...string) IO[Result] {
+	return IO[Result]{run: func() Result {
+		var stdout, stderr bytes.Buffer
+		cmd := exec.Command(name, args...)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			if stderr.Len() > 0 {
+				err = fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+			}
+			return Error[error]{Value: err}
+		}
+		return Ok[RequestBodyAsString]{Value: stdout.String()}
+	}}
+}
+
+// Example 5: Function to encapsulate reading all the lines of a reader,
+// so large bodies can be processed line by line instead of as one string
+// A read error or a line longer than 1MB stops the scan, the lines read
+// before it are returned and the error itself is discarded, so callers
+// that must tell a complete body from a truncated one should use a
+// bufio.Scanner directly and check its Err
+func ReadLinesIO(r io.Reader) IO[[]string] {
+	return IO[[]string]{run: func() []string {
+		var lines []string
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		return lines
+	}}
+}
+
+// Example 6: Function to encapsulate parsing and executing a text
+// template, returns Ok with the rendered text or Error when the
+// template cannot be parsed or executed
+func RenderTemplateIO(tmpl string, data any) IO[Result] {
+	return IO[Result]{run: func() Result {
+		t, err := template.New("template").Parse(tmpl)
+		if err != nil {
+			return Error[error]{Value: err}
+		}
+		var rendered strings.Builder
+		if err := t.Execute(&rendered, data); err != nil {
+			return Error[error]{Value: err}
+		}
+		return Ok[RequestBodyAsString]{Value: rendered.String()}
+	}}
+}
+
 /*
 
    AccOperation Monad
@@ -105,12 +287,86 @@ func (m AccOperation[T]) Chain(f func(T any) AccOperation[T]) AccOperation[T] {
 	return f(m.accValue)
 }
 
+// Function to chain accumulation operations that can be interrupted,
+// before running f it checks the context and, when it has been
+// cancelled, it sets the context error so the rest of the chain is skipped
+func (m AccOperation[T]) ChainCtx(ctx context.Context, f func(T) AccOperation[T]) AccOperation[T] {
+	if m.err != nil {
+		return AccOperation[T]{err: m.err}
+	}
+	if err := ctx.Err(); err != nil {
+		return AccOperation[T]{accValue: m.accValue, err: err}
+	}
+	return f(m.accValue)
+}
+
 // Function to execute the chained operations
 // in the AccOperation monad and return the final accumulated value
 func (m AccOperation[T]) Return() T {
 	return m.accValue
 }
 
+// Concurrent variant of AccOperation, the accumulated value is
+// protected by a mutex so it can be updated from multiple goroutines
+// It must be used through a pointer, see NewConcurrentAcc
+type ConcurrentAcc[T any] struct {
+	mu       sync.Mutex
+	accValue T
+}
+
+// Function to create a new instance of ConcurrentAcc
+// with an initial value
+func NewConcurrentAcc[T any](accValue T) *ConcurrentAcc[T] {
+	return &ConcurrentAcc[T]{accValue: accValue}
+}
+
+// Function to accumulate a new value, f receives the current
+// accumulated value and returns the new one while the lock is held
+func (m *ConcurrentAcc[T]) Add(f func(T) T) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.accValue = f(m.accValue)
+}
+
+// Function to read the current accumulated value
+func (m *ConcurrentAcc[T]) Value() T {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.accValue
+}
+
+// Counter built on AccOperation, counters are immutable values, so
+// each worker can count on its own CounterAcc without locks and the
+// partial counts are combined with Merge, which is associative and
+// commutative with the zero counter as identity (a commutative monoid)
+type CounterAcc struct {
+	acc AccOperation[int]
+}
+
+// Function to create a counter starting at zero
+func NewCounterAcc() CounterAcc {
+	return CounterAcc{acc: NewAccOperation(0, nil)}
+}
+
+// Function that returns a counter with one more unit
+func (c CounterAcc) Increment() CounterAcc {
+	return CounterAcc{acc: c.acc.Chain(func(count any) AccOperation[int] {
+		return NewAccOperation(count.(int)+1, nil)
+	})}
+}
+
+// Function that returns a counter with the sum of both counts
+func (c CounterAcc) Merge(other CounterAcc) CounterAcc {
+	return CounterAcc{acc: c.acc.Chain(func(count any) AccOperation[int] {
+		return NewAccOperation(count.(int)+other.Value(), nil)
+	})}
+}
+
+// Function that returns the current count
+func (c CounterAcc) Value() int {
+	return c.acc.Return()
+}
+
 /*
    Examples of AccOperation implementation
 */
@@ -160,6 +416,16 @@ func Map[T any](slice []T, mapper func(T) T) []T {
 	return result
 }
 
+// Creates a MapIndexed function that maps a slice like Map, but also
+// passes the index of each element and can change the element type
+func MapIndexed[T any, R any](slice []T, f func(int, T) R) []R {
+	result := make([]R, len(slice))
+	for i, v := range slice {
+		result[i] = f(i, v)
+	}
+	return result
+}
+
 // Creates a Reduce function that, taking a slice of a specific type,
 // a reducer, and an initial value, can reduce the slice to a single value
 func Reduce[T any, U any](slice []T, reducer func(U, T) U, initialValue U) U {
@@ -170,6 +436,195 @@ func Reduce[T any, U any](slice []T, reducer func(U, T) U, initialValue U) U {
 	return result
 }
 
+// Creates a SliceToMap function that indexes a slice by a key
+// derived from each element, when two elements share the same key
+// the later one overwrites the earlier one
+func SliceToMap[T any, K comparable](slice []T, keyFn func(T) K) map[K]T {
+	result := make(map[K]T, len(slice))
+	for _, v := range slice {
+		result[keyFn(v)] = v
+	}
+	return result
+}
+
+// Creates a Compose function that returns the composition of f and g,
+// the resulting function applies g first and then f, like (f . g) in Haskell
+func Compose[A any, B any, C any](f func(B) C, g func(A) B) func(A) C {
+	return func(a A) C {
+		return f(g(a))
+	}
+}
+
+// Creates a ComposeAll function that composes any number of functions
+// of the same type, applied from left to right like a pipeline
+// With no functions it returns the identity function
+func ComposeAll[T any](fs ...func(T) T) func(T) T {
+	return func(value T) T {
+		for _, f := range fs {
+			value = f(value)
+		}
+		return value
+	}
+}
+
+// Creates a Curry2 function that turns a function of two arguments
+// into a chain of functions of one argument, so it can be partially applied
+func Curry2[A any, B any, C any](f func(A, B) C) func(A) func(B) C {
+	return func(a A) func(B) C {
+		return func(b B) C {
+			return f(a, b)
+		}
+	}
+}
+
+// Creates a DistinctBy function that removes the elements whose key,
+// derived with keyFn, was already seen, keeping the first occurrence
+// of each key in its original order
+func DistinctBy[T any, K comparable](slice []T, keyFn func(T) K) []T {
+	seen := make(map[K]struct{}, len(slice))
+	var result []T
+	for _, v := range slice {
+		key := keyFn(v)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		result = append(result, v)
+	}
+	return result
+}
+
+// Creates a RoundRobin function that distributes the elements of a
+// slice across n buckets in turn, element i goes to bucket i % n
+// Returns an error when n is not positive
+func RoundRobin[T any](slice []T, n int) ([][]T, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("round robin needs a positive number of buckets, got %d", n)
+	}
+	buckets := make([][]T, n)
+	for i, v := range slice {
+		buckets[i%n] = append(buckets[i%n], v)
+	}
+	return buckets, nil
+}
+
+// Creates a Repeat function that builds a slice with n copies of value
+func Repeat[T any](value T, n int) []T {
+	result := make([]T, max(n, 0))
+	for i := range result {
+		result[i] = value
+	}
+	return result
+}
+
+// Creates a RepeatWith function that builds a slice of n elements
+// where the element at index i is f(i)
+func RepeatWith[T any](n int, f func(int) T) []T {
+	result := make([]T, max(n, 0))
+	for i := range result {
+		result[i] = f(i)
+	}
+	return result
+}
+
+// Creates a Zip3 function that groups the elements of three slices
+// by position, the result is as long as the shortest slice
+func Zip3[A, B, C any](as []A, bs []B, cs []C) []struct {
+	A A
+	B B
+	C C
+} {
+	result := make([]struct {
+		A A
+		B B
+		C C
+	}, min(len(as), len(bs), len(cs)))
+	for i := range result {
+		result[i].A = as[i]
+		result[i].B = bs[i]
+		result[i].C = cs[i]
+	}
+	return result
+}
+
+// Creates an Unzip function that splits a slice of pairs
+// into a slice of first elements and a slice of second elements
+func Unzip[A, B any](pairs []struct {
+	First  A
+	Second B
+}) ([]A, []B) {
+	as := make([]A, len(pairs))
+	bs := make([]B, len(pairs))
+	for i, pair := range pairs {
+		as[i] = pair.First
+		bs[i] = pair.Second
+	}
+	return as, bs
+}
+
+// Creates a PFilter function that evaluates the predicate concurrently,
+// with at most concurrency evaluations at the same time, and keeps the
+// elements that satisfy it in their original order
+// A concurrency lower than one means no limit
+func PFilter[T any](slice []T, concurrency int, pred func(T) bool) []T {
+	keep := make([]bool, len(slice))
+	forEachConcurrently(len(slice), concurrency, func(i int) {
+		keep[i] = pred(slice[i])
+	})
+
+	var result []T
+	for i, v := range slice {
+		if keep[i] {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// Creates an Identity function that returns its argument unchanged,
+// useful as a default mapper
+func Identity[T any](v T) T {
+	return v
+}
+
+// Creates a Const function that returns a function which ignores
+// its argument and always returns v
+func Const[T any, U any](v T) func(U) T {
+	return func(U) T {
+		return v
+	}
+}
+
+// Creates a SumBy function that adds up a numeric projection
+// of each element of a slice
+func SumBy[T any](slice []T, f func(T) float64) float64 {
+	return Reduce(slice, func(sum float64, v T) float64 { return sum + f(v) }, 0)
+}
+
+// Creates an AverageBy function that averages a numeric projection
+// of each element of a slice, it returns 0 for an empty slice
+func AverageBy[T any](slice []T, f func(T) float64) float64 {
+	if len(slice) == 0 {
+		return 0
+	}
+	return SumBy(slice, f) / float64(len(slice))
+}
+
+// Creates a SlidingReduce function that applies a reducer to every
+// window of consecutive elements of the given size, returning one
+// value per window, len(slice)-window+1 values in total
+// It returns nil when window is not positive or larger than the slice
+func SlidingReduce[T any, U any](slice []T, window int, reducer func([]T) U) []U {
+	if window <= 0 || window > len(slice) {
+		return nil
+	}
+	result := make([]U, len(slice)-window+1)
+	for i := range result {
+		result[i] = reducer(slice[i : i+window : i+window])
+	}
+	return result
+}
+
 /* ************************************************************** */
 
 // Structure that defines the parameters of the AsyncHttpGetCall function
@@ -209,7 +664,7 @@ func (a AsyncIOProcess[T]) Map(f func(T) T) AsyncIOProcess[T] {
 	return NewAsyncIOProcess(f(a.value))
 }
 
-func testing() {
+func asyncIOProcessExample() {
 	// Ejemplo de AsyncIOProcess
 	// Se crea una instancia de AsyncIOProcess con una función y un canal
 	// Se encadena una operación que recibe la función y el canal y los ejecuta
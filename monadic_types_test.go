@@ -0,0 +1,381 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func TestSliceToMap(t *testing.T) {
+	type page struct {
+		url  string
+		body string
+	}
+	pages := []page{{"a", "1"}, {"b", "2"}, {"a", "3"}}
+	got := SliceToMap(pages, func(p page) string { return p.url })
+	want := map[string]page{"a": {"a", "3"}, "b": {"b", "2"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SliceToMap = %v, want %v (last wins on collision)", got, want)
+	}
+}
+
+func TestCompose(t *testing.T) {
+	parse := func(s string) int {
+		n, _ := strconv.Atoi(s)
+		return n
+	}
+	double := func(n int) int { return n * 2 }
+	if got := Compose(double, parse)("21"); got != 42 {
+		t.Errorf("Compose(double, parse)(\"21\") = %d, want 42", got)
+	}
+	inc := func(n int) int { return n + 1 }
+	if got := ComposeAll(inc, double)(1); got != 4 {
+		t.Errorf("ComposeAll(inc, double)(1) = %d, want 4", got)
+	}
+}
+
+func TestCurry2(t *testing.T) {
+	add := func(a, b int) int { return a + b }
+	if got := Curry2(add)(3)(4); got != add(3, 4) {
+		t.Errorf("Curry2(add)(3)(4) = %d, want %d", got, add(3, 4))
+	}
+}
+
+func TestConcurrentAcc(t *testing.T) {
+	acc := NewConcurrentAcc(0)
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			acc.Add(func(n int) int { return n + 1 })
+		}()
+	}
+	wg.Wait()
+	if got := acc.Value(); got != 100 {
+		t.Errorf("Value() = %d, want 100", got)
+	}
+}
+
+func TestRetryIO(t *testing.T) {
+	runs := 0
+	poll := IO[string]{run: func() string {
+		runs++
+		if runs < 3 {
+			return ""
+		}
+		return "ready"
+	}}
+	got := RetryIO(poll, func(s string) bool { return s == "" }, 5).Run()
+	if got != "ready" || runs != 3 {
+		t.Errorf("RetryIO = %q after %d runs, want \"ready\" after 3", got, runs)
+	}
+}
+
+func TestGetEnv(t *testing.T) {
+	t.Setenv("DEVLAB_TEST_ENV", "value")
+	if got := GetEnv("DEVLAB_TEST_ENV").Run(); got != (Ok[RequestBodyAsString]{Value: "value"}) {
+		t.Errorf("GetEnv(set) = %v, want Ok{value}", got)
+	}
+	os.Unsetenv("DEVLAB_TEST_ENV")
+	if _, isErr := GetEnv("DEVLAB_TEST_ENV").Run().(Error[error]); !isErr {
+		t.Error("GetEnv(unset) did not return an Error")
+	}
+}
+
+func TestJoinIO(t *testing.T) {
+	pair := JoinIO(IO[string]{run: func() string { return "a" }}, IO[int]{run: func() int { return 1 }}).Run()
+	if pair.First != "a" || pair.Second != 1 {
+		t.Errorf("JoinIO = %+v, want {a 1}", pair)
+	}
+}
+
+func TestThenIO(t *testing.T) {
+	var log []string
+	first := IO[int]{run: func() int { log = append(log, "first"); return 0 }}
+	second := IO[string]{run: func() string { return "second" }}
+	if got := ThenIO(first, second).Run(); got != "second" {
+		t.Errorf("ThenIO = %q, want second", got)
+	}
+	if !reflect.DeepEqual(log, []string{"first"}) {
+		t.Errorf("log = %v, want [first]", log)
+	}
+}
+
+func TestChainCtxCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	called := false
+	result := NewAccOperation(1, nil).ChainCtx(ctx, func(v int) AccOperation[int] {
+		called = true
+		return NewAccOperation(v+1, nil)
+	})
+	if called {
+		t.Error("ChainCtx ran f with a cancelled context")
+	}
+	if !errors.Is(result.err, context.Canceled) {
+		t.Errorf("ChainCtx error = %v, want context.Canceled", result.err)
+	}
+}
+
+func TestDistinctBy(t *testing.T) {
+	type user struct {
+		ID   int
+		Name string
+	}
+	users := []user{{1, "a"}, {2, "b"}, {1, "c"}, {3, "d"}, {2, "e"}}
+	got := DistinctBy(users, func(u user) int { return u.ID })
+	want := []user{{1, "a"}, {2, "b"}, {3, "d"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DistinctBy = %v, want %v", got, want)
+	}
+}
+
+func TestRunCommand(t *testing.T) {
+	if got := RunCommand("echo", "hello").Run(); got != (Ok[RequestBodyAsString]{Value: "hello\n"}) {
+		t.Errorf("RunCommand(echo) = %v, want Ok{hello\\n}", got)
+	}
+	if _, isErr := RunCommand("devlab-missing-binary").Run().(Error[error]); !isErr {
+		t.Error("RunCommand with a missing binary did not return an Error")
+	}
+}
+
+func TestRoundRobin(t *testing.T) {
+	buckets, err := RoundRobin([]int{1, 2, 3, 4, 5, 6, 7}, 3)
+	if err != nil {
+		t.Fatalf("RoundRobin returned error %v", err)
+	}
+	want := [][]int{{1, 4, 7}, {2, 5}, {3, 6}}
+	if !reflect.DeepEqual(buckets, want) {
+		t.Errorf("RoundRobin = %v, want %v", buckets, want)
+	}
+	if _, err := RoundRobin([]int{1}, 0); err == nil {
+		t.Error("RoundRobin with zero buckets did not return an error")
+	}
+}
+
+func TestScheduleIO(t *testing.T) {
+	var runs int
+	stop := make(chan struct{})
+	out := ScheduleIO(IO[int]{run: func() int { runs++; return runs }}, time.Millisecond, stop)
+	for want := 1; want <= 3; want++ {
+		if got := <-out; got != want {
+			t.Errorf("emission %d = %d, want %d", want, got, want)
+		}
+	}
+	close(stop)
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case _, open := <-out:
+			if !open {
+				return
+			}
+		case <-timeout:
+			t.Fatal("output channel not closed after stop")
+		}
+	}
+}
+
+func TestRepeat(t *testing.T) {
+	for _, n := range []int{0, 1, 3} {
+		if got := Repeat("x", n); len(got) != n {
+			t.Errorf("Repeat(x, %d) has %d elements", n, len(got))
+		}
+	}
+	if got := Repeat("x", 3); !reflect.DeepEqual(got, []string{"x", "x", "x"}) {
+		t.Errorf("Repeat(x, 3) = %v, want [x x x]", got)
+	}
+	if got := RepeatWith(0, strconv.Itoa); len(got) != 0 {
+		t.Errorf("RepeatWith(0) = %v, want empty", got)
+	}
+	if got := RepeatWith(1, strconv.Itoa); !reflect.DeepEqual(got, []string{"0"}) {
+		t.Errorf("RepeatWith(1) = %v, want [0]", got)
+	}
+	if got := RepeatWith(3, strconv.Itoa); !reflect.DeepEqual(got, []string{"0", "1", "2"}) {
+		t.Errorf("RepeatWith(3) = %v, want [0 1 2]", got)
+	}
+}
+
+func TestZip3(t *testing.T) {
+	type triple = struct {
+		A int
+		B string
+		C bool
+	}
+	got := Zip3([]int{1, 2}, []string{"a", "b"}, []bool{true, false})
+	if want := []triple{{1, "a", true}, {2, "b", false}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Zip3 = %v, want %v", got, want)
+	}
+	got = Zip3([]int{1, 2, 3}, []string{"a"}, []bool{true, false})
+	if want := []triple{{1, "a", true}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Zip3 with ragged inputs = %v, want %v", got, want)
+	}
+}
+
+func TestUnzip(t *testing.T) {
+	firsts, seconds := Unzip([]struct {
+		First  string
+		Second int
+	}{{"a", 1}, {"b", 2}})
+	if !reflect.DeepEqual(firsts, []string{"a", "b"}) || !reflect.DeepEqual(seconds, []int{1, 2}) {
+		t.Errorf("Unzip = %v, %v, want [a b], [1 2]", firsts, seconds)
+	}
+}
+
+func TestPFilter(t *testing.T) {
+	const delay = 50 * time.Millisecond
+	isEven := func(n int) bool {
+		time.Sleep(delay)
+		return n%2 == 0
+	}
+	start := time.Now()
+	got := PFilter([]int{1, 2, 3, 4, 5, 6, 7, 8}, 4, isEven)
+	elapsed := time.Since(start)
+	if !reflect.DeepEqual(got, []int{2, 4, 6, 8}) {
+		t.Errorf("PFilter = %v, want [2 4 6 8]", got)
+	}
+	if elapsed >= 4*delay {
+		t.Errorf("PFilter took %v, want less than %v with 4 workers", elapsed, 4*delay)
+	}
+}
+
+func TestIdentityAndConst(t *testing.T) {
+	if got := Identity("x"); got != "x" {
+		t.Errorf("Identity(x) = %q, want x", got)
+	}
+	five := Const[int, string](5)
+	if five("a") != 5 || five("b") != 5 {
+		t.Error("Const(5) did not ignore its argument")
+	}
+}
+
+func TestDelayIO(t *testing.T) {
+	const d = 20 * time.Millisecond
+	start := time.Now()
+	if got := DelayIO(IO[int]{run: func() int { return 1 }}, d).Run(); got != 1 {
+		t.Errorf("DelayIO = %d, want 1", got)
+	}
+	if elapsed := time.Since(start); elapsed < d {
+		t.Errorf("DelayIO returned after %v, want at least %v", elapsed, d)
+	}
+}
+
+func TestSumByAndAverageBy(t *testing.T) {
+	type response struct{ Size int }
+	responses := []response{{10}, {20}, {30}}
+	size := func(r response) float64 { return float64(r.Size) }
+	if got := SumBy(responses, size); got != 60 {
+		t.Errorf("SumBy = %v, want 60", got)
+	}
+	if got := AverageBy(responses, size); got != 20 {
+		t.Errorf("AverageBy = %v, want 20", got)
+	}
+	if got := AverageBy(nil, size); got != 0 {
+		t.Errorf("AverageBy(empty) = %v, want 0", got)
+	}
+}
+
+func TestWithLockIO(t *testing.T) {
+	var mu sync.Mutex
+	counter := 0
+	increment := WithLockIO(&mu, IO[int]{run: func() int { counter++; return counter }})
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			increment.Run()
+		}()
+	}
+	wg.Wait()
+	if counter != 100 {
+		t.Errorf("counter = %d, want 100", counter)
+	}
+}
+
+func TestReadLinesIO(t *testing.T) {
+	got := ReadLinesIO(strings.NewReader("first\nsecond\r\nthird")).Run()
+	if want := []string{"first", "second", "third"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadLinesIO = %v, want %v", got, want)
+	}
+	failing := io.MultiReader(strings.NewReader("first\nsecond\n"), iotest.ErrReader(errors.New("read failed")))
+	if got, want := ReadLinesIO(failing).Run(), []string{"first", "second"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadLinesIO(failing reader) = %v, want the lines before the error %v", got, want)
+	}
+}
+
+func TestRenderTemplateIO(t *testing.T) {
+	got := RenderTemplateIO(`{"id": {{.ID}}}`, struct{ ID int }{7}).Run()
+	if got != (Ok[RequestBodyAsString]{Value: `{"id": 7}`}) {
+		t.Errorf("RenderTemplateIO = %v, want Ok{{\"id\": 7}}", got)
+	}
+	for _, tmpl := range []string{"{{.ID", "{{.Missing}}"} {
+		if _, isErr := RenderTemplateIO(tmpl, struct{ ID int }{7}).Run().(Error[error]); !isErr {
+			t.Errorf("RenderTemplateIO(%q) did not return an Error", tmpl)
+		}
+	}
+}
+
+func TestMapIndexed(t *testing.T) {
+	got := MapIndexed([]string{"a", "b", "c"}, func(i int, s string) string { return strconv.Itoa(i) + s })
+	if want := []string{"0a", "1b", "2c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MapIndexed = %v, want %v", got, want)
+	}
+}
+
+func TestRunCtxDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	slow := DelayIO(IO[int]{run: func() int { return 1 }}, time.Second)
+	start := time.Now()
+	if _, err := slow.RunCtx(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("RunCtx returned %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("RunCtx returned after %v, want it to return at the deadline", elapsed)
+	}
+}
+
+func TestSlidingReduce(t *testing.T) {
+	sum := func(window []int) float64 { return SumBy(window, func(n int) float64 { return float64(n) }) }
+	got := SlidingReduce([]int{1, 2, 3, 4, 5}, 3, sum)
+	if want := []float64{6, 9, 12}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SlidingReduce = %v, want %v", got, want)
+	}
+	if got := SlidingReduce([]int{1, 2}, 3, sum); len(got) != 0 {
+		t.Errorf("SlidingReduce with a window over the length = %v, want empty", got)
+	}
+}
+
+func TestCounterAccMerge(t *testing.T) {
+	partials := make([]CounterAcc, 4)
+	var wg sync.WaitGroup
+	for w := range partials {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			counter := NewCounterAcc()
+			for i := 0; i < 25; i++ {
+				counter = counter.Increment()
+			}
+			partials[w] = counter
+		}(w)
+	}
+	wg.Wait()
+	total := NewCounterAcc()
+	for _, partial := range partials {
+		total = total.Merge(partial)
+	}
+	if total.Value() != 100 {
+		t.Errorf("merged count = %d, want 100", total.Value())
+	}
+}
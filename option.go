@@ -0,0 +1,74 @@
+package main
+
+/*
+
+	The monadic Option interface is a data type that can be
+	Some or None, it is a way to represent a value that may
+	be absent without using nil pointers.
+
+	Some is a data type that wraps a present value and None
+	is a data type that represents the absence of a value.
+
+	This implementation is similar to the Maybe monad in Haskell and
+	Option in Rust, and it follows the same shape as the Result interface.
+
+*/
+
+type Option interface {
+	isOption()
+}
+type Some[T any] struct {
+	Value T
+}
+type None struct{}
+
+func (Some[T]) isOption() {}
+func (None) isOption()    {}
+
+// Unexported accessor used to reach the value of any Some
+// without knowing its type parameter
+func (s Some[T]) someValue() any { return s.Value }
+
+type someValuer interface {
+	someValue() any
+}
+
+// Function that collapses a nested Option into a single level
+// (the monadic join of Option), a Some whose value is another Option
+// is replaced by that inner Option, any other Option is returned unchanged
+func FlattenOption(o Option) Option {
+	if s, ok := o.(someValuer); ok {
+		if inner, ok := s.someValue().(Option); ok {
+			return inner
+		}
+	}
+	return o
+}
+
+// Function that converts a pointer into an Option,
+// None for a nil pointer and Some with the pointed value otherwise
+func FromPointer[T any](p *T) Option {
+	if p == nil {
+		return None{}
+	}
+	return Some[T]{Value: *p}
+}
+
+// Function that applies f to the value of a Some[A] and wraps the
+// result in a Some[B] (the functor map of Option), None and
+// Some values of a type other than A are returned as None
+func MapOption[A any, B any](o Option, f func(A) B) Option {
+	if s, ok := o.(Some[A]); ok {
+		return Some[B]{Value: f(s.Value)}
+	}
+	return None{}
+}
+
+// Function that returns the element at index i as a Some,
+// or None when the index is out of range (including negative indices)
+func At[T any](slice []T, i int) Option {
+	if i < 0 || i >= len(slice) {
+		return None{}
+	}
+	return Some[T]{Value: slice[i]}
+}
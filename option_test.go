@@ -0,0 +1,41 @@
+package main
+
+import "testing"
+
+func TestFlattenOption(t *testing.T) {
+	nested := Some[Some[int]]{Value: Some[int]{Value: 3}}
+	if got := FlattenOption(nested); got != (Some[int]{Value: 3}) {
+		t.Errorf("FlattenOption(Some[Some[int]]) = %v, want Some[int]{3}", got)
+	}
+	if got := FlattenOption(Some[None]{Value: None{}}); got != (None{}) {
+		t.Errorf("FlattenOption(Some[None]) = %v, want None", got)
+	}
+	if got := FlattenOption(Some[int]{Value: 1}); got != (Some[int]{Value: 1}) {
+		t.Errorf("FlattenOption(Some[int]) = %v, want it unchanged", got)
+	}
+}
+
+func TestMapOption(t *testing.T) {
+	double := func(n int) int { return n * 2 }
+	if got := MapOption(Some[int]{Value: 21}, double); got != (Some[int]{Value: 42}) {
+		t.Errorf("MapOption(Some) = %v, want Some{42}", got)
+	}
+	if got := MapOption(None{}, double); got != (None{}) {
+		t.Errorf("MapOption(None) = %v, want None", got)
+	}
+	if got := MapOption(Some[string]{Value: "21"}, double); got != (None{}) {
+		t.Errorf("MapOption(Some[string]) = %v, want None", got)
+	}
+}
+
+func TestAt(t *testing.T) {
+	slice := []string{"a", "b"}
+	if got := At(slice, 1); got != (Some[string]{Value: "b"}) {
+		t.Errorf("At(1) = %v, want Some{b}", got)
+	}
+	for _, i := range []int{-1, 2} {
+		if got := At(slice, i); got != (None{}) {
+			t.Errorf("At(%d) = %v, want None", i, got)
+		}
+	}
+}
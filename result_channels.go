@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"sync"
+)
+
+/*
+
+	Helpers to build pipelines over channels of Result values,
+	like the ones used by AsyncHttpGetCall and the call chains.
+
+*/
+
+// Function that broadcasts every Result received from in to all
+// the output channels, and closes them once in is closed
+// It blocks until in is closed, so it is usually run in its own
+// goroutine, and a slow consumer delays every other consumer
+func Fanout(in <-chan Result, outs ...chan<- Result) {
+	for result := range in {
+		for _, out := range outs {
+			out <- result
+		}
+	}
+	for _, out := range outs {
+		close(out)
+	}
+}
+
+// Function that applies f to every Result received from in and sends
+// the transformed Result on the returned channel, which is closed
+// once in is closed
+func MapChannel(in <-chan Result, f func(Result) Result) <-chan Result {
+	out := make(chan Result)
+	go func() {
+		defer close(out)
+		for result := range in {
+			out <- f(result)
+		}
+	}()
+	return out
+}
+
+// Function that folds the results received from in into a single
+// value, it returns when in is closed or when the context is cancelled,
+// in which case the value accumulated so far is returned
+func ReduceChannel[U any](ctx context.Context, in <-chan Result, reducer func(U, Result) U, initial U) U {
+	acc := initial
+	for {
+		select {
+		case <-ctx.Done():
+			return acc
+		case result, ok := <-in:
+			if !ok {
+				return acc
+			}
+			acc = reducer(acc, result)
+		}
+	}
+}
+
+// Wrapper over a channel of Result with helpers to send each variant,
+// meant for producers where more than one goroutine may end the
+// stream, any of them can call CloseOnce without a double-close panic
+type ResultChan struct {
+	ch   chan Result
+	once sync.Once
+}
+
+// Function to create a ResultChan with the given buffer size
+func NewResultChan(size int) *ResultChan {
+	return &ResultChan{ch: make(chan Result, size)}
+}
+
+// Function that returns the channel to receive the results from
+func (c *ResultChan) C() <-chan Result {
+	return c.ch
+}
+
+// Function that sends an Ok with the given body
+func (c *ResultChan) SendOk(v RequestBodyAsString) {
+	c.ch <- Ok[RequestBodyAsString]{Value: v}
+}
+
+// Function that sends an Error with the given error
+func (c *ResultChan) SendErr(err error) {
+	c.ch <- Error[error]{Value: err}
+}
+
+// Function that closes the channel, calling it more than once
+// does nothing instead of panicking
+func (c *ResultChan) CloseOnce() {
+	c.once.Do(func() { close(c.ch) })
+}
+
+// Function that splits a stream of results into a stream of bodies
+// and a stream of errors, like UnpackResults does for slices
+// Both channels are closed when in is closed or the context is
+// cancelled, and they are unbuffered, so they must be received from
+// concurrently, Ok results of other types are skipped
+func UnpackStream(ctx context.Context, in <-chan Result) (<-chan RequestBodyAsString, <-chan error) {
+	bodies := make(chan RequestBodyAsString)
+	errs := make(chan error)
+	go func() {
+		defer close(bodies)
+		defer close(errs)
+		for {
+			var result Result
+			select {
+			case <-ctx.Done():
+				return
+			case r, ok := <-in:
+				if !ok {
+					return
+				}
+				result = r
+			}
+			switch r := result.(type) {
+			case Ok[RequestBodyAsString]:
+				select {
+				case bodies <- r.Value:
+				case <-ctx.Done():
+					return
+				}
+			case errorValuer:
+				select {
+				case errs <- resultError(result):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return bodies, errs
+}
+
+// Function that sends a Result to the channel only the first time it
+// is called with the same once, later calls do nothing
+// The dispatch functions send every Result of a goroutine through it,
+// so a path that forgets to return after sending cannot deliver a
+// second Result, the send happens outside once, so a concurrent later
+// call returns at once instead of waiting for the first Result to be
+// received
+func sendOnce(ch chan<- Result, once *sync.Once, r Result) {
+	first := false
+	once.Do(func() { first = true })
+	if first {
+		ch <- r
+	}
+}
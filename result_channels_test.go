@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFanout(t *testing.T) {
+	in := make(chan Result)
+	outA, outB := make(chan Result), make(chan Result)
+	go Fanout(in, outA, outB)
+	go func() {
+		for _, body := range []RequestBodyAsString{"a", "b", "c"} {
+			in <- Ok[RequestBodyAsString]{Value: body}
+		}
+		close(in)
+	}()
+
+	var wg sync.WaitGroup
+	received := make([][]Result, 2)
+	for i, out := range []chan Result{outA, outB} {
+		wg.Add(1)
+		go func(i int, out chan Result) {
+			defer wg.Done()
+			for result := range out {
+				received[i] = append(received[i], result)
+			}
+		}(i, out)
+	}
+	wg.Wait()
+	for i, got := range received {
+		if len(got) != 3 {
+			t.Errorf("consumer %d received %v, want the 3 results", i, got)
+		}
+	}
+}
+
+func TestMapChannel(t *testing.T) {
+	in := make(chan Result, 3)
+	in <- Ok[RequestBodyAsString]{Value: "a"}
+	in <- Error[string]{Value: "e"}
+	in <- Ok[RequestBodyAsString]{Value: "b"}
+	close(in)
+	upper := func(r Result) Result {
+		if ok, isOk := r.(Ok[RequestBodyAsString]); isOk {
+			return Ok[RequestBodyAsString]{Value: strings.ToUpper(ok.Value)}
+		}
+		return r
+	}
+	var got []Result
+	for result := range MapChannel(in, upper) {
+		got = append(got, result)
+	}
+	want := []Result{Ok[RequestBodyAsString]{Value: "A"}, Error[string]{Value: "e"}, Ok[RequestBodyAsString]{Value: "B"}}
+	if !ResultsEqual(got, want) {
+		t.Errorf("MapChannel = %v, want %v", got, want)
+	}
+}
+
+func TestReduceChannelCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	in := make(chan Result)
+	go func() {
+		in <- Ok[RequestBodyAsString]{Value: "a"}
+		in <- Ok[RequestBodyAsString]{Value: "b"}
+		<-ctx.Done() // the stream is never closed
+	}()
+	count := func(acc int, r Result) int {
+		if acc+1 == 2 {
+			cancel()
+		}
+		return acc + 1
+	}
+	if got := ReduceChannel(ctx, in, count, 0); got != 2 {
+		t.Errorf("ReduceChannel = %d, want the partial count 2", got)
+	}
+}
+
+func TestResultChan(t *testing.T) {
+	c := NewResultChan(2)
+	errA := errors.New("a")
+	c.SendOk("body")
+	c.SendErr(errA)
+	c.CloseOnce()
+	c.CloseOnce()
+
+	var got []Result
+	for result := range c.C() {
+		got = append(got, result)
+	}
+	want := []Result{Ok[RequestBodyAsString]{Value: "body"}, Error[error]{Value: errA}}
+	if !ResultsEqual(got, want) {
+		t.Errorf("received %v, want %v", got, want)
+	}
+}
+
+func TestUnpackStreamCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan Result) // never closed
+	bodies, errs := UnpackStream(ctx, in)
+	in <- Ok[RequestBodyAsString]{Value: "a"}
+	if body := <-bodies; body != "a" {
+		t.Errorf("body = %q, want a", body)
+	}
+	cancel()
+	timeout := time.After(time.Second)
+	for bodies != nil || errs != nil {
+		select {
+		case _, open := <-bodies:
+			if !open {
+				bodies = nil
+			}
+		case _, open := <-errs:
+			if !open {
+				errs = nil
+			}
+		case <-timeout:
+			t.Fatal("output channels not closed after cancelling")
+		}
+	}
+}
+
+func TestSendOnce(t *testing.T) {
+	ch := make(chan Result, 2)
+	var once sync.Once
+	sendOnce(ch, &once, Ok[RequestBodyAsString]{Value: "first"})
+	sendOnce(ch, &once, Ok[RequestBodyAsString]{Value: "second"})
+	close(ch)
+	var got []Result
+	for result := range ch {
+		got = append(got, result)
+	}
+	if want := []Result{Ok[RequestBodyAsString]{Value: "first"}}; !ResultsEqual(got, want) {
+		t.Errorf("received %v, want only %v", got, want)
+	}
+}
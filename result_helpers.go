@@ -0,0 +1,617 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/*
+
+	Helpers built on top of the Result monad defined in main.go.
+
+	These functions work with any Ok or Error value, so they can be
+	used with the results returned by the HTTP call chains as well as
+	with any other Result produced by the user.
+
+*/
+
+// Returns the message carried by the value of an Error,
+// using Error() when the value is an error
+func errorMessage(value any) string {
+	if err, ok := value.(error); ok && err != nil {
+		return err.Error()
+	}
+	return fmt.Sprint(value)
+}
+
+// Returns the error carried by an Error result, values that are
+// not errors are converted using their message, Ok results
+// and Errors with a nil error return nil
+func resultError(r Result) error {
+	e, isError := r.(errorValuer)
+	if !isError {
+		return nil
+	}
+	switch value := e.errorValue().(type) {
+	case nil:
+		return nil
+	case error:
+		return value
+	default:
+		return errors.New(errorMessage(value))
+	}
+}
+
+/*
+   JSON serialization
+*/
+
+// Tagged JSON representation of a Result, only one of the
+// fields is present in a serialized Result
+type resultJSON struct {
+	Ok    json.RawMessage `json:"ok,omitempty"`
+	Error *string         `json:"error,omitempty"`
+}
+
+// Function that serializes a Result into a tagged JSON object,
+// {"ok": <value>} for an Ok and {"error": "<message>"} for an Error
+// The value of an Error is stored as its message, so an error
+// can be sent over the wire and rebuilt with UnmarshalResult
+func MarshalResult(r Result) ([]byte, error) {
+	switch r := r.(type) {
+	case okValuer:
+		value, err := json.Marshal(r.okValue())
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(resultJSON{Ok: value})
+	case errorValuer:
+		message := errorMessage(r.errorValue())
+		return json.Marshal(resultJSON{Error: &message})
+	}
+	return nil, fmt.Errorf("cannot marshal result of type %T", r)
+}
+
+// Function that rebuilds a Result serialized with MarshalResult
+// A JSON string in the ok field becomes an Ok[RequestBodyAsString],
+// any other JSON value becomes an Ok[any], and the error field
+// becomes an Error[error] with the original message
+func UnmarshalResult(data []byte) (Result, error) {
+	var tagged resultJSON
+	if err := json.Unmarshal(data, &tagged); err != nil {
+		return nil, err
+	}
+	if tagged.Error != nil {
+		return Error[error]{Value: errors.New(*tagged.Error)}, nil
+	}
+	if tagged.Ok == nil {
+		return nil, errors.New("result JSON has neither an ok nor an error field")
+	}
+	if strings.HasPrefix(strings.TrimSpace(string(tagged.Ok)), `"`) {
+		var body RequestBodyAsString
+		if err := json.Unmarshal(tagged.Ok, &body); err != nil {
+			return nil, err
+		}
+		return Ok[RequestBodyAsString]{Value: body}, nil
+	}
+	var value any
+	if err := json.Unmarshal(tagged.Ok, &value); err != nil {
+		return nil, err
+	}
+	return Ok[any]{Value: value}, nil
+}
+
+// Function that writes the results as newline delimited JSON,
+// one object per line in the format produced by MarshalResult
+func WriteResultsNDJSON(w io.Writer, results []Result) error {
+	for _, result := range results {
+		line, err := MarshalResult(result)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(append(line, '\n')); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Function that writes named results as CSV with a header row and
+// the columns name, status ("ok" or "error") and value (the Ok value
+// or the Error message), rows are sorted by name
+func WriteResultsCSV(w io.Writer, named map[string]Result) error {
+	names := make([]string, 0, len(named))
+	for name := range named {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"name", "status", "value"}); err != nil {
+		return err
+	}
+	for _, name := range names {
+		var row []string
+		switch result := named[name].(type) {
+		case okValuer:
+			row = []string{name, "ok", fmt.Sprint(result.okValue())}
+		case errorValuer:
+			row = []string{name, "error", errorMessage(result.errorValue())}
+		default:
+			return fmt.Errorf("cannot write result of type %T", result)
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+/*
+   Gob persistence
+*/
+
+func init() {
+	gob.Register(Ok[string]{})
+	gob.Register(Error[string]{})
+}
+
+// Function that persists results with encoding/gob, only Ok[string]
+// (Ok[RequestBodyAsString]) and Error values are supported
+// gob cannot encode the error interface, so the value of an Error
+// is converted to its message and stored as an Error[string]
+func EncodeResults(w io.Writer, results []Result) error {
+	encoded := make([]Result, len(results))
+	for i, result := range results {
+		switch r := result.(type) {
+		case Ok[string]:
+			encoded[i] = r
+		case errorValuer:
+			encoded[i] = Error[string]{Value: errorMessage(r.errorValue())}
+		default:
+			return fmt.Errorf("cannot encode result of type %T", result)
+		}
+	}
+	return gob.NewEncoder(w).Encode(encoded)
+}
+
+// Function that reloads results persisted with EncodeResults,
+// Errors are rebuilt as Error[error] with the original message
+func DecodeResults(r io.Reader) ([]Result, error) {
+	var results []Result
+	if err := gob.NewDecoder(r).Decode(&results); err != nil {
+		return nil, err
+	}
+	for i, result := range results {
+		if e, isError := result.(Error[string]); isError {
+			results[i] = Error[error]{Value: errors.New(e.Value)}
+		}
+	}
+	return results, nil
+}
+
+/*
+   Sequencing
+*/
+
+// Function that chains a Result-returning function on the value of
+// an Ok[T] (monadic bind of Result), Errors are returned unchanged
+// and an Ok of a type other than T becomes an Error
+func ChainResult[T any](r Result, f func(T) Result) Result {
+	switch value := r.(type) {
+	case Ok[T]:
+		return f(value.Value)
+	case errorValuer:
+		return r
+	}
+	return Error[error]{Value: fmt.Errorf("cannot chain result of type %T", r)}
+}
+
+// Function that composes two Result-returning functions (Kleisli
+// composition), the value of the Ok returned by f is passed to g
+// B must be the type of the value in the Ok returned by f, otherwise
+// the composed function returns an Error, C is the type of the value
+// in the Ok returned by g and only documents the composition, so the
+// type parameters have to be given explicitly
+func KleisliResult[A any, B any, C any](f func(A) Result, g func(B) Result) func(A) Result {
+	return func(a A) Result {
+		return ChainResult(f(a), g)
+	}
+}
+
+// Function that runs each step in order and stops at the first
+// Error, returning it, otherwise it returns the Result of the last step
+// Steps can capture variables of the enclosing scope to pass values
+// between them, approximating the do-notation of Haskell
+// Returns nil when no steps are given
+func Do(steps ...func() Result) Result {
+	var last Result
+	for _, step := range steps {
+		last = step()
+		if _, isError := last.(errorValuer); isError {
+			return last
+		}
+	}
+	return last
+}
+
+// Function that runs f and returns its value in an Ok[T], or an
+// Error[error] when f panics, bridging panic-prone code into Results
+// A panic with an error value is wrapped so errors.Is still matches it
+func Try[T any](f func() T) (result Result) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			if err, isErr := recovered.(error); isErr {
+				result = Error[error]{Value: fmt.Errorf("panic: %w", err)}
+			} else {
+				result = Error[error]{Value: fmt.Errorf("panic: %v", recovered)}
+			}
+		}
+	}()
+	return Ok[T]{Value: f()}
+}
+
+// Function that returns the body of an Ok[RequestBodyAsString],
+// or defaultBody for an Error or any other Result
+func WithDefault(r Result, defaultBody RequestBodyAsString) RequestBodyAsString {
+	if body, isBody := r.(Ok[RequestBodyAsString]); isBody {
+		return body.Value
+	}
+	return defaultBody
+}
+
+/*
+   Validation
+*/
+
+// Function that checks the value of an Ok[T] with a predicate,
+// turning it into an Error[error] with errMsg when the predicate fails
+// Errors and Ok values of other types are returned unchanged
+func Validate[T any](r Result, pred func(T) bool, errMsg string) Result {
+	if ok, isOk := r.(Ok[T]); isOk && !pred(ok.Value) {
+		return Error[error]{Value: errors.New(errMsg)}
+	}
+	return r
+}
+
+// Function that transforms the value of an Ok[T] with f, returning
+// an Ok with the new body or an Error when f fails, so transformation
+// and validation happen in one step
+// Errors and Ok values of other types are returned unchanged
+func MapOrFail[T any](r Result, f func(T) (RequestBodyAsString, error)) Result {
+	ok, isOk := r.(Ok[T])
+	if !isOk {
+		return r
+	}
+	body, err := f(ok.Value)
+	if err != nil {
+		return Error[error]{Value: err}
+	}
+	return Ok[RequestBodyAsString]{Value: body}
+}
+
+// Function that splits an Ok[[]RequestBodyAsString] into one
+// Ok[RequestBodyAsString] per body, any other Result (including
+// Errors) is returned as a single-element slice
+func SplitResultSlice(r Result) []Result {
+	bodies, isBodies := r.(Ok[[]RequestBodyAsString])
+	if !isBodies {
+		return []Result{r}
+	}
+	results := make([]Result, len(bodies.Value))
+	for i, body := range bodies.Value {
+		results[i] = Ok[RequestBodyAsString]{Value: body}
+	}
+	return results
+}
+
+/*
+   Ordering
+*/
+
+// Function that returns a sorted copy of the results, Ok results
+// come first ordered by their body and Errors are kept at the end
+// in their original relative order
+func SortResultsByBody(results []Result) []Result {
+	sorted := make([]Result, len(results))
+	copy(sorted, results)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, aIsOk := sorted[i].(okValuer)
+		b, bIsOk := sorted[j].(okValuer)
+		if aIsOk && bIsOk {
+			return fmt.Sprint(a.okValue()) < fmt.Sprint(b.okValue())
+		}
+		return aIsOk && !bIsOk
+	})
+	return sorted
+}
+
+/*
+   Comparison
+*/
+
+// Function that compares two results, they are equal when they are
+// the same variant with the same type parameter and their values are
+// deeply equal, two nil results are equal and nil differs from any result
+func ResultEqual(a, b Result) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// Function that compares two slices of results element by element
+// with ResultEqual
+func ResultsEqual(a, b []Result) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !ResultEqual(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+   Error extraction
+*/
+
+// Function that returns the errors of the Error results, skipping
+// the Ok results, unlike UnpackResults the returned slice is
+// not padded with nil values and has one entry per error
+func CollectErrors(results []Result) []error {
+	var errs []error
+	for _, result := range results {
+		if err := resultError(result); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// Function that combines the errors of the Error results into a
+// single error with errors.Join, so a batch can return one error up
+// the stack, it returns nil when there are no errors
+func JoinErrors(results []Result) error {
+	return errors.Join(CollectErrors(results)...)
+}
+
+// Function that turns a slice of errors back into results, a non-nil
+// error becomes an Error[error] and nil becomes an empty Ok, the
+// inverse of the error extraction of UnpackResults
+func ErrorsToResults(errs []error) []Result {
+	results := make([]Result, len(errs))
+	for i, err := range errs {
+		if err != nil {
+			results[i] = Error[error]{Value: err}
+		} else {
+			results[i] = Ok[RequestBodyAsString]{}
+		}
+	}
+	return results
+}
+
+// Function that groups the Error results by the concrete type of the
+// value they carry (like "*url.Error"), and the Ok results under the
+// "ok" key, to see which kinds of errors dominate a batch
+// Errors carrying a nil value are grouped under "nil"
+func PartitionByErrorType(results []Result) map[string][]Result {
+	groups := make(map[string][]Result)
+	for _, result := range results {
+		switch r := result.(type) {
+		case okValuer:
+			groups["ok"] = append(groups["ok"], result)
+		case errorValuer:
+			key := "nil"
+			if value := r.errorValue(); value != nil {
+				key = reflect.TypeOf(value).String()
+			}
+			groups[key] = append(groups[key], result)
+		}
+	}
+	return groups
+}
+
+/*
+   Pattern matching
+*/
+
+// Function that calls onOk with the value of an Ok or onErr with
+// the value of an Error and returns what the callback returns,
+// an expression alternative to a type switch over the result
+// It panics when the result is nil, since it is neither Ok nor Error
+func Match[R any](r Result, onOk func(any) R, onErr func(any) R) R {
+	switch r := r.(type) {
+	case okValuer:
+		return onOk(r.okValue())
+	case errorValuer:
+		return onErr(r.errorValue())
+	}
+	panic("Match: nil Result")
+}
+
+/*
+   JSON path extraction
+*/
+
+// Function that navigates the JSON body of an Ok[RequestBodyAsString]
+// following a dot separated path of object keys and array indices
+// (for example "value.id" or "items.0.name"), and returns an Ok with
+// the value found, strings as is and any other value as JSON
+// It returns an Error when the body is not valid JSON or the path is
+// missing, and Errors are returned unchanged
+// Numbers are kept as written in the body, so large integer IDs are
+// not rounded by a conversion to float64
+func ExtractJSONPath(r Result, path string) Result {
+	if _, isError := r.(errorValuer); isError {
+		return r
+	}
+	body, isBody := r.(Ok[RequestBodyAsString])
+	if !isBody {
+		return Error[error]{Value: fmt.Errorf("cannot extract a JSON path from %T", r)}
+	}
+	decoder := json.NewDecoder(strings.NewReader(body.Value))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return Error[error]{Value: err}
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return Error[error]{Value: errors.New("invalid JSON: data after the top-level value")}
+	}
+	if path != "" {
+		for _, key := range strings.Split(path, ".") {
+			switch node := value.(type) {
+			case map[string]any:
+				child, found := node[key]
+				if !found {
+					return Error[error]{Value: fmt.Errorf("JSON path %q: key %q not found", path, key)}
+				}
+				value = child
+			case []any:
+				index, err := strconv.Atoi(key)
+				if err != nil || index < 0 || index >= len(node) {
+					return Error[error]{Value: fmt.Errorf("JSON path %q: invalid index %q", path, key)}
+				}
+				value = node[index]
+			default:
+				return Error[error]{Value: fmt.Errorf("JSON path %q: cannot descend into %q", path, key)}
+			}
+		}
+	}
+	if s, isString := value.(string); isString {
+		return Ok[RequestBodyAsString]{Value: s}
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return Error[error]{Value: err}
+	}
+	return Ok[RequestBodyAsString]{Value: string(encoded)}
+}
+
+/*
+   Regular expressions
+*/
+
+// Function that returns an Ok with the first match of the pattern in
+// the body of an Ok[RequestBodyAsString], or an Error when there is
+// no match or the pattern is invalid, Errors are returned unchanged
+func MatchRegexp(r Result, pattern string) Result {
+	if _, isError := r.(errorValuer); isError {
+		return r
+	}
+	body, isBody := r.(Ok[RequestBodyAsString])
+	if !isBody {
+		return Error[error]{Value: fmt.Errorf("cannot match a regexp against %T", r)}
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return Error[error]{Value: err}
+	}
+	match := re.FindStringIndex(body.Value)
+	if match == nil {
+		return Error[error]{Value: fmt.Errorf("no match for pattern %q", pattern)}
+	}
+	return Ok[RequestBodyAsString]{Value: body.Value[match[0]:match[1]]}
+}
+
+/*
+   Batch summary
+*/
+
+// Structure that describes the outcome of a batch of results,
+// Errors holds the errors of the failed results in order
+type BatchSummary struct {
+	Total, OK, Failed int
+	Errors            []error
+}
+
+// Function that builds the BatchSummary of a slice of results in one pass
+func Summarize(results []Result) BatchSummary {
+	summary := BatchSummary{Total: len(results)}
+	for _, result := range results {
+		switch result.(type) {
+		case okValuer:
+			summary.OK++
+		case errorValuer:
+			summary.Failed++
+			if err := resultError(result); err != nil {
+				summary.Errors = append(summary.Errors, err)
+			}
+		}
+	}
+	return summary
+}
+
+/*
+   Deduplication
+*/
+
+// Function that removes the Ok results whose value was already seen
+// in a previous Ok of the same type, keeping the first one, while
+// all the Errors are kept, the original order is preserved
+// Ok values that are not comparable (like slices) are always kept
+func DistinctResults(results []Result) []Result {
+	seen := make(map[Result]struct{})
+	var distinct []Result
+	for _, result := range results {
+		if _, isOk := result.(okValuer); isOk && reflect.ValueOf(result).Comparable() {
+			if _, dup := seen[result]; dup {
+				continue
+			}
+			seen[result] = struct{}{}
+		}
+		distinct = append(distinct, result)
+	}
+	return distinct
+}
+
+// Function that concatenates two batches of results removing the
+// Ok results already seen (see DistinctResults) and keeping all the
+// Errors, useful when combining the results of two sources
+func MergeBatches(a, b []Result) []Result {
+	merged := make([]Result, 0, len(a)+len(b))
+	merged = append(merged, a...)
+	merged = append(merged, b...)
+	return DistinctResults(merged)
+}
+
+/*
+   Folding
+*/
+
+// Function that folds the bodies of the Ok[RequestBodyAsString]
+// results with step, it stops at the first Error result or at the
+// first error returned by step, returning the value accumulated so
+// far and that error
+// Ok results of other types are skipped
+func FoldResults[U any](results []Result, step func(U, RequestBodyAsString) (U, error), initial U) (U, error) {
+	acc := initial
+	for _, result := range results {
+		if err := resultError(result); err != nil {
+			return acc, err
+		}
+		body, isBody := result.(Ok[RequestBodyAsString])
+		if !isBody {
+			continue
+		}
+		next, err := step(acc, body.Value)
+		if err != nil {
+			return acc, err
+		}
+		acc = next
+	}
+	return acc, nil
+}
@@ -0,0 +1,419 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestMarshalResultRoundTrip(t *testing.T) {
+	data, err := MarshalResult(Ok[RequestBodyAsString]{Value: "body"})
+	if err != nil || string(data) != `{"ok":"body"}` {
+		t.Fatalf("MarshalResult(Ok) = %s, %v", data, err)
+	}
+	got, err := UnmarshalResult(data)
+	if err != nil || got != (Ok[RequestBodyAsString]{Value: "body"}) {
+		t.Errorf("UnmarshalResult(%s) = %v, %v", data, got, err)
+	}
+
+	data, err = MarshalResult(Error[error]{Value: errors.New("boom")})
+	if err != nil || string(data) != `{"error":"boom"}` {
+		t.Fatalf("MarshalResult(Error) = %s, %v", data, err)
+	}
+	got, err = UnmarshalResult(data)
+	if e, ok := got.(Error[error]); err != nil || !ok || e.Value.Error() != "boom" {
+		t.Errorf("UnmarshalResult(%s) = %v, %v", data, got, err)
+	}
+}
+
+func TestUnmarshalResultNull(t *testing.T) {
+	data, _ := MarshalResult(Ok[any]{Value: nil})
+	got, err := UnmarshalResult(data)
+	if err != nil || got != (Ok[any]{Value: nil}) {
+		t.Errorf("UnmarshalResult(%s) = %#v, %v, want Ok[any]{nil}", data, got, err)
+	}
+}
+
+func TestDoStopsAtFirstError(t *testing.T) {
+	var body RequestBodyAsString
+	thirdRan := false
+	got := Do(
+		func() Result {
+			body = "first"
+			return Ok[RequestBodyAsString]{Value: body}
+		},
+		func() Result { return Error[error]{Value: errors.New(body + " failed")} },
+		func() Result {
+			thirdRan = true
+			return Ok[RequestBodyAsString]{Value: "third"}
+		},
+	)
+	if e, ok := got.(Error[error]); !ok || e.Value.Error() != "first failed" {
+		t.Errorf("Do = %v, want the Error of the second step", got)
+	}
+	if thirdRan {
+		t.Error("Do ran the step after the Error")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	nonEmpty := func(s RequestBodyAsString) bool { return s != "" }
+	ok := Ok[RequestBodyAsString]{Value: "body"}
+	if got := Validate(ok, nonEmpty, "empty body"); got != ok {
+		t.Errorf("Validate(passing) = %v, want it unchanged", got)
+	}
+	got := Validate(Ok[RequestBodyAsString]{}, nonEmpty, "empty body")
+	if e, isErr := got.(Error[error]); !isErr || e.Value.Error() != "empty body" {
+		t.Errorf("Validate(failing) = %v, want Error{empty body}", got)
+	}
+	failed := Error[error]{Value: errors.New("boom")}
+	if got := Validate(failed, nonEmpty, "empty body"); got != failed {
+		t.Errorf("Validate(Error) = %v, want it unchanged", got)
+	}
+}
+
+func TestSortResultsByBody(t *testing.T) {
+	errA := Error[error]{Value: errors.New("a")}
+	errB := Error[error]{Value: errors.New("b")}
+	results := []Result{errA, Ok[RequestBodyAsString]{Value: "c"}, errB, Ok[RequestBodyAsString]{Value: "a"}}
+	want := []Result{Ok[RequestBodyAsString]{Value: "a"}, Ok[RequestBodyAsString]{Value: "c"}, errA, errB}
+	if got := SortResultsByBody(results); !reflect.DeepEqual(got, want) {
+		t.Errorf("SortResultsByBody = %v, want %v", got, want)
+	}
+}
+
+func TestResultsEqual(t *testing.T) {
+	a := []Result{Ok[RequestBodyAsString]{Value: "x"}, Error[error]{Value: errors.New("e")}}
+	b := []Result{Ok[RequestBodyAsString]{Value: "x"}, Error[error]{Value: errors.New("e")}}
+	if !ResultsEqual(a, b) {
+		t.Error("ResultsEqual of equal slices = false")
+	}
+	if ResultsEqual(a, []Result{Error[error]{Value: errors.New("x")}, a[1]}) {
+		t.Error("ResultsEqual with a different variant = true")
+	}
+	if ResultsEqual(a, []Result{Ok[RequestBodyAsString]{Value: "y"}, a[1]}) {
+		t.Error("ResultsEqual with a different value = true")
+	}
+}
+
+func TestCollectErrors(t *testing.T) {
+	errA, errB := errors.New("a"), errors.New("b")
+	tests := []struct {
+		name    string
+		results []Result
+		want    int
+	}{
+		{"all errors", []Result{Error[error]{Value: errA}, Error[error]{Value: errB}}, 2},
+		{"no errors", []Result{Ok[RequestBodyAsString]{Value: "x"}}, 0},
+		{"mixed", []Result{Error[error]{Value: errA}, Ok[RequestBodyAsString]{Value: "x"}, Error[string]{Value: "b"}}, 2},
+	}
+	for _, tt := range tests {
+		if got := CollectErrors(tt.results); len(got) != tt.want {
+			t.Errorf("%s: CollectErrors returned %d errors, want %d", tt.name, len(got), tt.want)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	onOk := func(v any) string { return "ok:" + v.(RequestBodyAsString) }
+	onErr := func(v any) string { return "error:" + errorMessage(v) }
+	if got := Match(Result(Ok[RequestBodyAsString]{Value: "body"}), onOk, onErr); got != "ok:body" {
+		t.Errorf("Match(Ok) = %q, want ok:body", got)
+	}
+	if got := Match(Result(Error[error]{Value: errors.New("boom")}), onOk, onErr); got != "error:boom" {
+		t.Errorf("Match(Error) = %q, want error:boom", got)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("Match(nil) did not panic")
+		}
+	}()
+	Match(nil, onOk, onErr)
+}
+
+func TestExtractJSONPath(t *testing.T) {
+	body := Ok[RequestBodyAsString]{Value: `{"value": {"id": 9007199254740993, "items": [{"name": "first"}]}}`}
+	tests := []struct {
+		path string
+		want Result
+	}{
+		{"value.id", Ok[RequestBodyAsString]{Value: "9007199254740993"}},
+		{"value.items.0.name", Ok[RequestBodyAsString]{Value: "first"}},
+	}
+	for _, tt := range tests {
+		if got := ExtractJSONPath(body, tt.path); got != tt.want {
+			t.Errorf("ExtractJSONPath(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+	if _, isErr := ExtractJSONPath(body, "value.missing").(Error[error]); !isErr {
+		t.Error("ExtractJSONPath with a missing path did not return an Error")
+	}
+	for _, malformed := range []string{`{"value":`, `{"value": 1} trailing`} {
+		if _, isErr := ExtractJSONPath(Ok[RequestBodyAsString]{Value: malformed}, "value").(Error[error]); !isErr {
+			t.Errorf("ExtractJSONPath(%q) did not return an Error", malformed)
+		}
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	errA := errors.New("a")
+	summary := Summarize([]Result{
+		Ok[RequestBodyAsString]{Value: "x"},
+		Error[error]{Value: errA},
+		Ok[RequestBodyAsString]{Value: "y"},
+	})
+	if summary.Total != 3 || summary.OK != 2 || summary.Failed != 1 {
+		t.Errorf("Summarize = %+v, want Total 3, OK 2, Failed 1", summary)
+	}
+	if len(summary.Errors) != 1 || summary.Errors[0] != errA {
+		t.Errorf("Summarize errors = %v, want [%v]", summary.Errors, errA)
+	}
+}
+
+func TestDistinctResults(t *testing.T) {
+	errA, errB := errors.New("a"), errors.New("b")
+	got := DistinctResults([]Result{
+		Ok[RequestBodyAsString]{Value: "same"},
+		Error[error]{Value: errA},
+		Ok[RequestBodyAsString]{Value: "same"},
+		Error[error]{Value: errB},
+		Ok[RequestBodyAsString]{Value: "other"},
+	})
+	want := []Result{
+		Ok[RequestBodyAsString]{Value: "same"},
+		Error[error]{Value: errA},
+		Error[error]{Value: errB},
+		Ok[RequestBodyAsString]{Value: "other"},
+	}
+	if !ResultsEqual(got, want) {
+		t.Errorf("DistinctResults = %v, want %v", got, want)
+	}
+}
+
+func TestFoldResults(t *testing.T) {
+	results := []Result{Ok[RequestBodyAsString]{Value: "ab"}, Ok[RequestBodyAsString]{Value: "cde"}}
+	length := func(acc int, body RequestBodyAsString) (int, error) { return acc + len(body), nil }
+	if total, err := FoldResults(results, length, 0); total != 5 || err != nil {
+		t.Errorf("FoldResults = (%d, %v), want (5, <nil>)", total, err)
+	}
+
+	errTooLong := errors.New("too long")
+	limited := func(acc int, body RequestBodyAsString) (int, error) {
+		if len(body) > 2 {
+			return acc, errTooLong
+		}
+		return acc + len(body), nil
+	}
+	if total, err := FoldResults(results, limited, 0); total != 2 || err != errTooLong {
+		t.Errorf("FoldResults = (%d, %v), want (2, %v)", total, err, errTooLong)
+	}
+}
+
+func TestWriteResultsNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	results := []Result{Ok[RequestBodyAsString]{Value: "x"}, Error[error]{Value: errors.New("a")}, Ok[RequestBodyAsString]{Value: "y"}}
+	if err := WriteResultsNDJSON(&buf, results); err != nil {
+		t.Fatalf("WriteResultsNDJSON returned error %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != len(results) {
+		t.Fatalf("wrote %d lines, want %d", len(lines), len(results))
+	}
+	for i, line := range lines {
+		if !json.Valid([]byte(line)) {
+			t.Errorf("line %d is not valid JSON: %s", i, line)
+		}
+	}
+}
+
+func TestMapOrFail(t *testing.T) {
+	errNegative := errors.New("negative")
+	format := func(n int) (RequestBodyAsString, error) {
+		if n < 0 {
+			return "", errNegative
+		}
+		return strconv.Itoa(n), nil
+	}
+	if got := MapOrFail(Ok[int]{Value: 7}, format); got != (Ok[RequestBodyAsString]{Value: "7"}) {
+		t.Errorf("MapOrFail(Ok{7}) = %v, want Ok{7}", got)
+	}
+	if got := MapOrFail(Ok[int]{Value: -1}, format); got != (Error[error]{Value: errNegative}) {
+		t.Errorf("MapOrFail(Ok{-1}) = %v, want Error{negative}", got)
+	}
+	errA := Error[error]{Value: errors.New("a")}
+	if got := MapOrFail(errA, format); got != errA {
+		t.Errorf("MapOrFail(Error) = %v, want it unchanged", got)
+	}
+}
+
+func TestJoinErrors(t *testing.T) {
+	if err := JoinErrors([]Result{Ok[RequestBodyAsString]{Value: "x"}}); err != nil {
+		t.Errorf("JoinErrors with no errors = %v, want nil", err)
+	}
+	errA, errB := errors.New("a"), errors.New("b")
+	if err := JoinErrors([]Result{Error[error]{Value: errA}}); !errors.Is(err, errA) {
+		t.Errorf("JoinErrors with one error = %v, want it to wrap %v", err, errA)
+	}
+	err := JoinErrors([]Result{Error[error]{Value: errA}, Ok[RequestBodyAsString]{Value: "x"}, Error[error]{Value: errB}})
+	if !errors.Is(err, errA) || !errors.Is(err, errB) {
+		t.Errorf("JoinErrors with two errors = %v, want it to wrap both", err)
+	}
+}
+
+func TestTry(t *testing.T) {
+	if got := Try(func() int { return 1 }); got != (Ok[int]{Value: 1}) {
+		t.Errorf("Try(normal) = %v, want Ok{1}", got)
+	}
+	errBoom := errors.New("boom")
+	got := Try(func() int { panic(errBoom) })
+	if err := resultError(got); !errors.Is(err, errBoom) {
+		t.Errorf("Try(panicking) = %v, want an Error wrapping %v", got, errBoom)
+	}
+}
+
+func TestWriteResultsCSV(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteResultsCSV(&buf, map[string]Result{
+		"users":  Ok[RequestBodyAsString]{Value: "a,\"b\""},
+		"orders": Error[error]{Value: errors.New("boom")},
+	})
+	if err != nil {
+		t.Fatalf("WriteResultsCSV returned error %v", err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("cannot parse the CSV back: %v", err)
+	}
+	want := [][]string{{"name", "status", "value"}, {"orders", "error", "boom"}, {"users", "ok", "a,\"b\""}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %v, want %v", rows, want)
+	}
+}
+
+func TestMergeBatches(t *testing.T) {
+	errA := errors.New("a")
+	a := []Result{Ok[RequestBodyAsString]{Value: "x"}, Error[error]{Value: errA}}
+	b := []Result{Ok[RequestBodyAsString]{Value: "x"}, Ok[RequestBodyAsString]{Value: "y"}, Error[error]{Value: errA}}
+	want := []Result{Ok[RequestBodyAsString]{Value: "x"}, Error[error]{Value: errA}, Ok[RequestBodyAsString]{Value: "y"}, Error[error]{Value: errA}}
+	if got := MergeBatches(a, b); !ResultsEqual(got, want) {
+		t.Errorf("MergeBatches = %v, want %v", got, want)
+	}
+}
+
+func TestSplitResultSlice(t *testing.T) {
+	got := SplitResultSlice(Ok[[]RequestBodyAsString]{Value: []RequestBodyAsString{"a", "b"}})
+	want := []Result{Ok[RequestBodyAsString]{Value: "a"}, Ok[RequestBodyAsString]{Value: "b"}}
+	if !ResultsEqual(got, want) {
+		t.Errorf("SplitResultSlice(Ok) = %v, want %v", got, want)
+	}
+	errA := Error[error]{Value: errors.New("a")}
+	if got := SplitResultSlice(errA); !ResultsEqual(got, []Result{errA}) {
+		t.Errorf("SplitResultSlice(Error) = %v, want [%v]", got, errA)
+	}
+}
+
+type quotaError struct{}
+
+func (quotaError) Error() string { return "quota exceeded" }
+
+func TestPartitionByErrorType(t *testing.T) {
+	urlErr := &url.Error{Op: "Get", URL: "http://example.com", Err: errors.New("refused")}
+	groups := PartitionByErrorType([]Result{
+		Ok[RequestBodyAsString]{Value: "x"},
+		Error[error]{Value: urlErr},
+		Error[error]{Value: quotaError{}},
+		Error[error]{Value: urlErr},
+	})
+	want := map[string]int{"ok": 1, "*url.Error": 2, "main.quotaError": 1}
+	if len(groups) != len(want) {
+		t.Errorf("PartitionByErrorType returned groups %v, want %v", groups, want)
+	}
+	for key, n := range want {
+		if len(groups[key]) != n {
+			t.Errorf("group %q has %d results, want %d", key, len(groups[key]), n)
+		}
+	}
+}
+
+func TestResultEqual(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Result
+		want bool
+	}{
+		{"equal Oks", Ok[[]string]{Value: []string{"a"}}, Ok[[]string]{Value: []string{"a"}}, true},
+		{"differing Oks", Ok[RequestBodyAsString]{Value: "a"}, Ok[RequestBodyAsString]{Value: "b"}, false},
+		{"Ok vs Error", Ok[string]{Value: "a"}, Error[string]{Value: "a"}, false},
+		{"both nil", nil, nil, true},
+		{"nil vs Ok", nil, Ok[string]{Value: "a"}, false},
+	}
+	for _, tt := range tests {
+		if got := ResultEqual(tt.a, tt.b); got != tt.want {
+			t.Errorf("%s: ResultEqual = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeDecodeResultsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.gob")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := []Result{Ok[RequestBodyAsString]{Value: "body"}, Error[error]{Value: errors.New("boom")}}
+	if err := EncodeResults(file, results); err != nil {
+		t.Fatalf("EncodeResults returned error %v", err)
+	}
+	file.Close()
+
+	file, err = os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	decoded, err := DecodeResults(file)
+	if err != nil {
+		t.Fatalf("DecodeResults returned error %v", err)
+	}
+	if len(decoded) != 2 || decoded[0] != results[0] || resultError(decoded[1]).Error() != "boom" {
+		t.Errorf("decoded %v, want %v", decoded, results)
+	}
+}
+
+func TestErrorsToResults(t *testing.T) {
+	errA := errors.New("a")
+	got := ErrorsToResults([]error{nil, errA, nil})
+	want := []Result{Ok[RequestBodyAsString]{}, Error[error]{Value: errA}, Ok[RequestBodyAsString]{}}
+	if !ResultsEqual(got, want) {
+		t.Errorf("ErrorsToResults = %v, want %v", got, want)
+	}
+}
+
+func TestMatchRegexp(t *testing.T) {
+	body := Ok[RequestBodyAsString]{Value: `{"id": 42}`}
+	if got := MatchRegexp(body, `[0-9]+`); got != (Ok[RequestBodyAsString]{Value: "42"}) {
+		t.Errorf("MatchRegexp(match) = %v, want Ok{42}", got)
+	}
+	for _, pattern := range []string{`[a-z]{5}`, `(`} {
+		if _, isErr := MatchRegexp(body, pattern).(Error[error]); !isErr {
+			t.Errorf("MatchRegexp(%q) did not return an Error", pattern)
+		}
+	}
+}
+
+func TestWithDefault(t *testing.T) {
+	if got := WithDefault(Ok[RequestBodyAsString]{Value: "body"}, "default"); got != "body" {
+		t.Errorf("WithDefault(Ok) = %q, want body", got)
+	}
+	if got := WithDefault(Error[error]{Value: errors.New("a")}, "default"); got != "default" {
+		t.Errorf("WithDefault(Error) = %q, want default", got)
+	}
+}
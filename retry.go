@@ -0,0 +1,206 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+/*
+
+	Retries for HTTP GET calls that return Result values.
+
+	A request is retried when it fails with a transport error or when
+	the server answers with 429 Too Many Requests or a 5xx status,
+	waiting between attempts following an exponential backoff schedule,
+	unless a 429 or 503 response says how long to wait with Retry-After.
+	Both waits are capped at retryMaxDelay, so a server cannot stall a
+	batch by asking for a very long Retry-After.
+
+*/
+
+// Delay before the first retry, doubled on every following retry
+var retryBaseDelay = 100 * time.Millisecond
+
+// Longest delay waited between two attempts, for both the backoff
+// schedule and the Retry-After header
+var retryMaxDelay = 30 * time.Second
+
+// Returns the backoff delay to wait before the given retry (starting at 0)
+func retryBackoff(retry int) time.Duration {
+	if retry > 30 || retryBaseDelay<<retry > retryMaxDelay {
+		return retryMaxDelay
+	}
+	return retryBaseDelay << retry
+}
+
+// Reports whether a response status is worth retrying
+func isRetryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= 500
+}
+
+// Returns the delay requested by the Retry-After header of a 429 or
+// 503 response, given either in seconds or as an HTTP date, and
+// false when the response does not carry a valid one
+// The delay is capped at retryMaxDelay
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return 0, false
+	}
+	value := strings.TrimSpace(resp.Header.Get("Retry-After"))
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return min(max(time.Duration(seconds)*time.Second, 0), retryMaxDelay), true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return min(max(time.Until(date), 0), retryMaxDelay), true
+	}
+	return 0, false
+}
+
+// RetryBudget is a number of retries shared by all the requests
+// of a batch, so a few flaky URLs cannot multiply the load on the
+// server, once it is exhausted no request is retried anymore
+// It is safe to use from multiple goroutines
+type RetryBudget struct {
+	remaining atomic.Int64
+}
+
+// Function to create a RetryBudget with a total number of retries
+func NewRetryBudget(total int) *RetryBudget {
+	b := &RetryBudget{}
+	b.remaining.Store(int64(total))
+	return b
+}
+
+// Function that takes one retry from the budget,
+// returns false when the budget is exhausted
+func (b *RetryBudget) TryAcquire() bool {
+	for {
+		remaining := b.remaining.Load()
+		if remaining <= 0 {
+			return false
+		}
+		if b.remaining.CompareAndSwap(remaining, remaining-1) {
+			return true
+		}
+	}
+}
+
+// Function that returns the number of retries left in the budget
+func (b *RetryBudget) Remaining() int {
+	return int(b.remaining.Load())
+}
+
+// Function that makes an HTTP GET request with up to maxAttempts
+// attempts, honoring the Retry-After header when present, every
+// retry takes one unit from the budget (a nil budget does not
+// limit retries)
+// When the last attempt still gets a retryable status an Error
+// with a *StatusError is returned
+// It also returns the number of attempts made
+func getWithRetry(client *http.Client, url string, maxAttempts int, budget *RetryBudget) (Result, int) {
+	var result Result
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			return Error[error]{Value: err}, attempt + 1
+		}
+		resp, body, err := fetchBody(client, req)
+		delay := retryBackoff(attempt)
+		switch {
+		case err != nil:
+			result = Error[error]{Value: err}
+		case isRetryableStatus(resp.StatusCode):
+			result = Error[error]{Value: &StatusError{StatusCode: resp.StatusCode, Status: resp.Status}}
+			if wait, ok := retryAfter(resp); ok {
+				delay = wait
+			}
+		default:
+			return Ok[RequestBodyAsString]{Value: string(body)}, attempt + 1
+		}
+		if attempt+1 >= maxAttempts || (budget != nil && !budget.TryAcquire()) {
+			return result, attempt + 1
+		}
+		time.Sleep(delay)
+	}
+}
+
+// Function that makes a chain of HTTP GET calls where every request
+// is attempted up to maxAttempts times, while the total number of
+// retries of the whole batch is limited by the shared budget
+// A concurrency lower than one means no limit
+func ChainWithRetryBudget(urls []string, maxAttempts int, budget *RetryBudget, concurrency int) []Result {
+	return chainWithConcurrency(urls, concurrency, func(url string) Result {
+		result, _ := getWithRetry(http.DefaultClient, url, maxAttempts, budget)
+		return result
+	})
+}
+
+// Number of attempts made for each request by ChainWithEnvelopes
+const envelopeMaxAttempts = 3
+
+// Structure that wraps the Result of a request with the metadata of
+// how it was obtained
+type ResultEnvelope struct {
+	URL      string
+	Attempts int
+	Duration time.Duration
+	Result   Result
+}
+
+// Function that makes a chain of HTTP GET calls, retrying each request
+// up to envelopeMaxAttempts times, and returns a ResultEnvelope per
+// URL in input order with the number of attempts and the total time
+// spent on the request, including the waits between attempts
+// A concurrency lower than one means no limit
+func ChainWithEnvelopes(urls []string, concurrency int) []ResultEnvelope {
+	envelopes := make([]ResultEnvelope, len(urls))
+	forEachConcurrently(len(urls), concurrency, func(i int) {
+		start := time.Now()
+		result, attempts := getWithRetry(http.DefaultClient, urls[i], envelopeMaxAttempts, nil)
+		envelopes[i] = ResultEnvelope{
+			URL:      urls[i],
+			Attempts: attempts,
+			Duration: time.Since(start),
+			Result:   result,
+		}
+	})
+	return envelopes
+}
+
+// Function that makes a chain of HTTP GET calls and then, for up to
+// rounds more times, makes again only the calls that failed, waiting
+// retryBackoff between rounds, the successful results are never
+// fetched again
+// Like in getWithRetry, transport errors and 429 or 5xx responses are
+// failures, a call still failing after the last round keeps its Error
+// A concurrency lower than one means no limit
+func ChainWithSubsetRetry(urls []string, rounds int, concurrency int) []Result {
+	results := make([]Result, len(urls))
+	failed := make([]bool, len(urls))
+	forEachConcurrently(len(urls), concurrency, func(i int) {
+		results[i], failed[i] = getAndClassify(urls[i])
+	})
+	for round := 0; round < rounds; round++ {
+		var retry []int
+		for i := range urls {
+			if failed[i] {
+				retry = append(retry, i)
+			}
+		}
+		if len(retry) == 0 {
+			break
+		}
+		time.Sleep(retryBackoff(round))
+		forEachConcurrently(len(retry), concurrency, func(j int) {
+			i := retry[j]
+			results[i], failed[i] = getAndClassify(urls[i])
+		})
+	}
+	return results
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Shortens the backoff between retries for the duration of the test
+func fastRetries(t *testing.T) {
+	t.Helper()
+	base := retryBaseDelay
+	retryBaseDelay = time.Millisecond
+	t.Cleanup(func() { retryBaseDelay = base })
+}
+
+func TestChainWithRetryBudget(t *testing.T) {
+	fastRetries(t)
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	urls := []string{server.URL + "/a", server.URL + "/b", server.URL + "/c"}
+	budget := NewRetryBudget(2)
+	results := ChainWithRetryBudget(urls, 5, budget, 0)
+	if retries := int(hits.Load()) - len(urls); retries > 2 {
+		t.Errorf("made %d retries, want at most 2", retries)
+	}
+	if budget.Remaining() != 0 {
+		t.Errorf("budget.Remaining() = %d, want 0", budget.Remaining())
+	}
+	for i, result := range results {
+		if _, isError := result.(Error[error]); !isError {
+			t.Errorf("results[%d] = %v, want an Error", i, result)
+		}
+	}
+}
+
+// Starts a test server that answers the first request with a 503 and
+// the given Retry-After header, and every later request with a 200
+func newRetryAfterServer(t *testing.T, retryAfter string) *httptest.Server {
+	t.Helper()
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 1 {
+			w.Header().Set("Retry-After", retryAfter)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGetWithRetryHonorsRetryAfter(t *testing.T) {
+	fastRetries(t)
+	server := newRetryAfterServer(t, "1")
+	start := time.Now()
+	result, attempts := getWithRetry(http.DefaultClient, server.URL, 3, nil)
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %v, want at least 1s", elapsed)
+	}
+	if result != (Ok[RequestBodyAsString]{Value: "ok"}) || attempts != 2 {
+		t.Errorf("getWithRetry = (%v, %d), want (Ok{ok}, 2)", result, attempts)
+	}
+}
+
+func TestGetWithRetryCapsRetryAfter(t *testing.T) {
+	fastRetries(t)
+	maxDelay := retryMaxDelay
+	retryMaxDelay = 10 * time.Millisecond
+	t.Cleanup(func() { retryMaxDelay = maxDelay })
+
+	server := newRetryAfterServer(t, "3600")
+	start := time.Now()
+	result, _ := getWithRetry(http.DefaultClient, server.URL, 3, nil)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("retried after %v, want the delay capped at %v", elapsed, retryMaxDelay)
+	}
+	if result != (Ok[RequestBodyAsString]{Value: "ok"}) {
+		t.Errorf("getWithRetry = %v, want Ok{ok}", result)
+	}
+}
+
+func TestChainWithEnvelopes(t *testing.T) {
+	fastRetries(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	urls := []string{server.URL + "/ok", server.URL + "/fail"}
+	envelopes := ChainWithEnvelopes(urls, 0)
+	for i, want := range []int{1, envelopeMaxAttempts} {
+		envelope := envelopes[i]
+		if envelope.URL != urls[i] || envelope.Attempts != want || envelope.Duration <= 0 {
+			t.Errorf("envelopes[%d] = %+v, want URL %s, %d attempts and a duration", i, envelope, urls[i], want)
+		}
+	}
+	if _, isErr := envelopes[1].Result.(Error[error]); !isErr {
+		t.Errorf("envelopes[1].Result = %v, want an Error", envelopes[1].Result)
+	}
+}
+
+func TestChainWithSubsetRetry(t *testing.T) {
+	fastRetries(t)
+	var mu sync.Mutex
+	hits := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.URL.Path]++
+		n := hits[r.URL.Path]
+		mu.Unlock()
+		if r.URL.Path == "/flaky" && n == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, r.URL.Path)
+	}))
+	defer server.Close()
+
+	results := ChainWithSubsetRetry([]string{server.URL + "/ok", server.URL + "/flaky"}, 2, 0)
+	want := []Result{Ok[RequestBodyAsString]{Value: "/ok"}, Ok[RequestBodyAsString]{Value: "/flaky"}}
+	if !ResultsEqual(results, want) {
+		t.Errorf("ChainWithSubsetRetry = %v, want %v", results, want)
+	}
+	if hits["/ok"] != 1 || hits["/flaky"] != 2 {
+		t.Errorf("hits = %v, want /ok fetched once and /flaky twice", hits)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import "sync"
+
+/*
+
+	SingleflightClient coalesces concurrent HTTP GET calls to the same
+	URL into a single network call whose Result is shared by all the
+	callers, modelled on golang.org/x/sync/singleflight.
+
+*/
+
+type SingleflightClient struct {
+	get   func(url string) Result
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+// In-flight call shared by the callers of the same URL
+type flightCall struct {
+	wg     sync.WaitGroup
+	result Result
+}
+
+// Function to create a SingleflightClient that sends its requests
+// with the given Client, a nil Client uses http.DefaultClient
+func NewSingleflightClient(client *Client) *SingleflightClient {
+	get := httpGetResult
+	if client != nil {
+		get = client.Get
+	}
+	return &SingleflightClient{get: get, calls: make(map[string]*flightCall)}
+}
+
+// Function that makes an HTTP GET request, when a request to the
+// same URL is already in flight it waits for it and returns its
+// Result instead of making a new one
+// Only concurrent calls are coalesced, the Result is not cached
+func (s *SingleflightClient) Get(url string) Result {
+	s.mu.Lock()
+	if call, ok := s.calls[url]; ok {
+		s.mu.Unlock()
+		call.wg.Wait()
+		return call.result
+	}
+	call := &flightCall{}
+	call.wg.Add(1)
+	s.calls[url] = call
+	s.mu.Unlock()
+
+	call.result = s.get(url)
+	call.wg.Done()
+
+	s.mu.Lock()
+	delete(s.calls, url)
+	s.mu.Unlock()
+	return call.result
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSingleflightClientCoalesces(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		time.Sleep(100 * time.Millisecond)
+		fmt.Fprint(w, "shared")
+	}))
+	defer server.Close()
+
+	client := NewSingleflightClient(nil)
+	var wg sync.WaitGroup
+	results := make([]Result, 10)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = client.Get(server.URL)
+		}(i)
+	}
+	wg.Wait()
+	if n := hits.Load(); n != 1 {
+		t.Errorf("server hit %d times, want 1", n)
+	}
+	for i, result := range results {
+		if result != (Ok[RequestBodyAsString]{Value: "shared"}) {
+			t.Errorf("results[%d] = %v, want Ok{shared}", i, result)
+		}
+	}
+}